@@ -0,0 +1,142 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type BaselineEntry struct {
+	URL       string `json:"url"`
+	Origin    string `json:"origin,omitempty"`
+	Method    string `json:"method,omitempty"`
+	Preflight *bool  `json:"preflight,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type Baseline []BaselineEntry
+
+func loadBaseline(path string, missingOK bool) (Baseline, error) {
+	var baseline Baseline
+	err := loadDoc(path, &baseline)
+	if missingOK && errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return baseline, err
+}
+
+func (b Baseline) Apply(record Record) Record {
+	if record.Status == StatusPass || record.Status == StatusSkipped {
+		return record
+	}
+
+	reasons := recordReasons(record)
+	for _, entry := range b {
+		if entry.URL != record.URL {
+			continue
+		}
+		if entry.Origin != "" && entry.Origin != record.Origin {
+			continue
+		}
+		if entry.Method != "" && !strings.EqualFold(entry.Method, record.Method) {
+			continue
+		}
+		if entry.Preflight != nil && *entry.Preflight != record.Preflight {
+			continue
+		}
+		if entry.Reason != "" && !slices.Contains(reasons, entry.Reason) {
+			continue
+		}
+
+		record.Status = StatusIgnored
+		return record
+	}
+	return record
+}
+
+func recordReasons(record Record) []string {
+	if record.Error != "" {
+		return []string{"error"}
+	}
+
+	var reasons []string
+	if !record.Expected && record.Allowed {
+		reasons = append(reasons, "allowed")
+	}
+	for _, v := range record.Checks {
+		if !v.OK && !slices.Contains(reasons, string(v.Rule)) {
+			reasons = append(reasons, string(v.Rule))
+		}
+	}
+	return reasons
+}
+
+type baselineWriter struct {
+	path string
+
+	m          sync.Mutex
+	entries    Baseline
+	incomplete bool
+}
+
+func (w *baselineWriter) Report(record Record) {
+	if record.Status == StatusPass {
+		return
+	}
+	if record.Status == StatusSkipped {
+		w.m.Lock()
+		defer w.m.Unlock()
+
+		w.incomplete = true
+		return
+	}
+
+	entry := BaselineEntry{
+		URL:       record.URL,
+		Origin:    record.Origin,
+		Method:    record.Method,
+		Preflight: &record.Preflight,
+	}
+	if reasons := recordReasons(record); len(reasons) != 0 {
+		entry.Reason = reasons[0]
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.entries = append(w.entries, entry)
+}
+
+func (w *baselineWriter) Close() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.incomplete {
+		slog.Warn("not updating baseline after incomplete run", "path", w.path)
+		return nil
+	}
+
+	slices.SortFunc(w.entries, func(e1, e2 BaselineEntry) int {
+		return cmp.Or(
+			strings.Compare(e1.URL, e2.URL),
+			strings.Compare(e1.Method, e2.Method),
+			strings.Compare(e1.Origin, e2.Origin),
+		)
+	})
+	entries := w.entries
+	if entries == nil {
+		entries = Baseline{}
+	}
+
+	buf, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(w.path, append(buf, '\n'), 0644)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+func TestBaselineApply(t *testing.T) {
+	yes, no := true, false
+
+	failed := Record{
+		URL:      "https://api.example/x",
+		Origin:   "https://a.example",
+		Method:   "GET",
+		Status:   StatusFail,
+		Expected: true,
+		Checks:   []checkcors.Verdict{{Rule: checkcors.RuleVary}},
+	}
+	deleted := failed
+	deleted.Method = "DELETE"
+	preflight := deleted
+	preflight.Preflight = true
+	errored := failed
+	errored.Status = StatusError
+	errored.Error = "perform request: timeout"
+	allowed := failed
+	allowed.Expected = false
+	allowed.Allowed = true
+	allowed.Checks = nil
+	passed := failed
+	passed.Status = StatusPass
+	skipped := errored
+	skipped.Status = StatusSkipped
+
+	tests := []struct {
+		name    string
+		entries Baseline
+		record  Record
+		want    Status
+	}{
+		{"Empty", nil, failed, StatusFail},
+		{"URL", Baseline{{URL: failed.URL}}, failed, StatusIgnored},
+		{"OtherURL", Baseline{{URL: "https://api.example/y"}}, failed, StatusFail},
+		{"Origin", Baseline{{URL: failed.URL, Origin: "https://a.example"}}, failed, StatusIgnored},
+		{"OtherOrigin", Baseline{{URL: failed.URL, Origin: "https://b.example"}}, failed, StatusFail},
+		{"Method", Baseline{{URL: failed.URL, Method: "get"}}, failed, StatusIgnored},
+		{"OtherMethod", Baseline{{URL: failed.URL, Method: "GET"}}, deleted, StatusFail},
+		{"AnyMethod", Baseline{{URL: failed.URL}}, deleted, StatusIgnored},
+		{"Preflight", Baseline{{URL: failed.URL, Method: "DELETE", Preflight: &yes}}, preflight, StatusIgnored},
+		{"NotPreflight", Baseline{{URL: failed.URL, Method: "DELETE", Preflight: &no}}, preflight, StatusFail},
+		{"AnyPreflight", Baseline{{URL: failed.URL, Method: "DELETE"}}, preflight, StatusIgnored},
+		{"Reason", Baseline{{URL: failed.URL, Reason: "vary"}}, failed, StatusIgnored},
+		{"OtherReason", Baseline{{URL: failed.URL, Reason: "allow-origin"}}, failed, StatusFail},
+		{"AllowedReason", Baseline{{URL: failed.URL, Reason: "allowed"}}, allowed, StatusIgnored},
+		{"ErrorReason", Baseline{{URL: failed.URL, Reason: "error"}}, errored, StatusIgnored},
+		{"ErrorOtherReason", Baseline{{URL: failed.URL, Reason: "vary"}}, errored, StatusError},
+		{"Passed", Baseline{{URL: failed.URL}}, passed, StatusPass},
+		{"Skipped", Baseline{{URL: failed.URL}}, skipped, StatusSkipped},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.entries.Apply(test.record)
+			if got.Status != test.want {
+				t.Errorf("status = %v, want %v", got.Status, test.want)
+			}
+		})
+	}
+}
+
+func TestBaselineWriterMethods(t *testing.T) {
+	failed := Record{
+		URL:      "https://api.example/x",
+		Method:   "GET",
+		Status:   StatusFail,
+		Expected: true,
+		Checks:   []checkcors.Verdict{{Rule: checkcors.RuleOrigin}},
+	}
+	var w baselineWriter
+	w.Report(failed)
+
+	preflight := failed
+	preflight.Method = "DELETE"
+	preflight.Preflight = true
+	if got := w.entries.Apply(preflight); got.Status != StatusFail {
+		t.Errorf("known GET failure hid a preflight failure: status = %v", got.Status)
+	}
+	if got := w.entries.Apply(failed); got.Status != StatusIgnored {
+		t.Errorf("known GET failure not ignored: status = %v", got.Status)
+	}
+}
@@ -13,70 +13,122 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
-	"sync/atomic"
+	"syscall"
 	"time"
-)
 
-type Checker struct {
-	Client    *http.Client
-	ReqHeader http.Header
-}
+	"deedles.dev/checkcors/pkg/checkcors"
+	"gopkg.in/yaml.v3"
+)
 
-func (c Checker) Check(ctx context.Context, url string) (bool, error) {
-	slog := slog.With("url", url)
+func loadURLs(paths, args []string, stdin []string, rerr *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, path := range paths {
+			lines := loadLines(path, rerr)
+			if path == "-" {
+				lines = slices.Values(stdin)
+			}
+			for line := range lines {
+				if !yield(line) {
+					return
+				}
+			}
+			if *rerr != nil {
+				return
+			}
+		}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return false, fmt.Errorf("create request: %w", err)
+		for _, arg := range args {
+			if !yield(arg) {
+				return
+			}
+		}
 	}
-	req.Header = c.ReqHeader
+}
 
-	rsp, err := c.Client.Do(req)
+func loadJSON(path string, data any) error {
+	buf, err := os.ReadFile(path)
 	if err != nil {
-		return false, fmt.Errorf("perform request: %w", err)
+		return err
 	}
-	defer rsp.Body.Close()
 
-	_, err = io.Copy(io.Discard, rsp.Body)
-	if err != nil {
-		return false, fmt.Errorf("read body: %w", err)
-	}
+	return json.Unmarshal(buf, &data)
+}
 
-	return checkHeaders(
-		slog,
-		rsp.Header,
-		map[string]string{
-			"Access-Control-Allow-Methods": "GET",
-			"Access-Control-Allow-Origin":  "*",
-		},
-	), nil
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			v[key] = stringKeys(val)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = stringKeys(val)
+		}
+		return m
+	case []any:
+		for i, val := range v {
+			v[i] = stringKeys(val)
+		}
+		return v
+	default:
+		return v
+	}
 }
 
-func checkHeaders(slog *slog.Logger, h http.Header, expect map[string]string) bool {
-	ok := true
-	for name, val := range expect {
-		actual := h.Get(name)
-		if actual != val {
-			slog.Error("header mismatch", "header", name, "expected", val, "got", actual)
-			ok = false
+func loadDoc(path string, data any) error {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return err
 		}
+
+		var doc any
+		err = yaml.Unmarshal(buf, &doc)
+		if err != nil {
+			return err
+		}
+
+		buf, err = json.Marshal(stringKeys(doc))
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(buf, data)
+
+	default:
+		return loadJSON(path, data)
 	}
+}
+
+type stringsFlag []string
 
-	return ok
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
 }
 
-func loadJSON(path string, data any) error {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
+func (f *stringsFlag) Set(val string) error {
+	*f = append(*f, val)
+	return nil
+}
 
-	return json.Unmarshal(buf, &data)
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-func loadURLs(path string, rerr *error) iter.Seq[string] {
+func loadLines(path string, rerr *error) iter.Seq[string] {
 	return func(yield func(string) bool) {
 		file, err := os.Open(path)
 		if err != nil {
@@ -85,7 +137,13 @@ func loadURLs(path string, rerr *error) iter.Seq[string] {
 		}
 		defer file.Close()
 
-		s := bufio.NewScanner(file)
+		scanLines(file, rerr)(yield)
+	}
+}
+
+func scanLines(r io.Reader, rerr *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		s := bufio.NewScanner(r)
 		for s.Scan() {
 			line := s.Text()
 			trimmed := strings.TrimSpace(line)
@@ -101,13 +159,168 @@ func loadURLs(path string, rerr *error) iter.Seq[string] {
 	}
 }
 
+func credentialHeader(cookie, authorization string) http.Header {
+	h := make(http.Header)
+	if cookie != "" {
+		h.Set("Cookie", cookie)
+	}
+	if authorization != "" {
+		h.Set("Authorization", authorization)
+	}
+	return h
+}
+
+func concat[T any](seqs ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, seq := range seqs {
+			for v := range seq {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func checkAll(ctx context.Context, checker checkcors.Checker, targets iter.Seq[Target], concurrency int, report func(Record)) {
+	type job struct {
+		url     string
+		origin  checkcors.Origin
+		checker checkcors.Checker
+	}
+	jobs := make(chan job)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				if ctx.Err() != nil {
+					result := checkcors.Result{
+						URL:       job.url,
+						Origin:    job.origin,
+						Method:    job.checker.Method,
+						Preflight: job.checker.Preflight,
+					}
+					report(skippedRecord(result, ctx.Err()))
+					continue
+				}
+
+				result, err := job.checker.Check(ctx, job.url, job.origin)
+				if err != nil && ctx.Err() != nil {
+					report(skippedRecord(result, err))
+					continue
+				}
+				report(newRecord(result, err))
+			}
+		}()
+	}
+
+	for target := range targets {
+		for _, checker := range target.checkers(checker) {
+			for _, origin := range target.Origins {
+				jobs <- job{url: target.URL, origin: origin, checker: checker}
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
 func run(ctx context.Context) error {
 	reqheaderfile := flag.String("reqheaders", "", "path to JSON file with request headers")
-	urlfile := flag.String("urls", "", "path to file with list of URLs to check")
+	var urlfiles stringsFlag
+	flag.Var(&urlfiles, "urls", "path to file with list of URLs to check, or - for stdin; may be repeated")
+	var sitemaps stringsFlag
+	flag.Var(&sitemaps, "sitemap", "URL of a sitemap or sitemap index to load URLs from; may be repeated")
+	crawl := flag.Bool("crawl", false, "crawl same-origin links starting from the input URLs")
+	depth := flag.Int("depth", 2, "maximum link depth when crawling")
+	crawllimit := flag.Int("crawl-limit", 1000, "maximum number of URLs to discover when crawling")
+	configfile := flag.String("config", "", "path to JSON or YAML file with per-URL targets and expectations")
+	openapifile := flag.String("openapi", "", "path to a JSON or YAML OpenAPI document to derive URLs and methods from")
+	openapiserver := flag.String("openapi-server", "", "base URL to use instead of the servers listed in the OpenAPI document")
+	harfile := flag.String("har", "", "path to a HAR file whose cross-origin requests should be replayed")
+	expectfile := flag.String("expect", "", "path to JSON file with expected response headers")
+	preflight := flag.Bool("preflight", false, "perform a CORS preflight (OPTIONS) check instead of a GET")
+	originlist := flag.String("origins", "", "comma-separated list, or @file, of origins that should be allowed")
+	denylist := flag.String("deny-origins", "", "comma-separated list, or @file, of origins that should be denied")
+	methods := flag.String("methods", "GET", "comma-separated list of methods to check; non-simple methods are checked with a preflight")
+	headers := flag.String("headers", "", "comma-separated list of headers to request permission for in preflight requests")
+	output := flag.String("output", "log", "output format: log, json, or ndjson")
+	junitfile := flag.String("junit", "", "path to write a JUnit XML report to")
+	concurrency := flag.Int("concurrency", 10, "maximum number of checks to run at once")
+	rate := flag.Float64("rate", 0, "maximum requests per second across all hosts, or 0 for no limit")
+	hostrate := flag.Float64("host-rate", 0, "maximum requests per second to any single host, or 0 for no limit")
+	credentials := flag.Bool("credentials", false, "check requests as credentialed requests")
+	cookie := flag.String("cookie", "", "Cookie header to send with credentialed requests")
+	authorization := flag.String("authorization", "", "Authorization header to send with credentialed requests")
+	proxy := flag.String("proxy", "", "HTTP, HTTPS, or SOCKS5 proxy URL to send requests through")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification")
+	cafile := flag.String("cacert", "", "path to a PEM bundle of additional CA certificates to trust")
+	var resolve stringsFlag
+	flag.Var(&resolve, "resolve", "host:port:addr to connect to addr instead of resolving host; may be repeated")
+	redirects := checkcors.RedirectFollow
+	flag.Var(&redirects, "redirects", "how to handle redirects: follow, none, or validate each hop")
+	cachechecks := flag.Bool("cache-checks", false, "also check that responses can be safely cached")
+	retries := flag.Int("retries", 0, "number of times to retry a check after a transient failure")
+	retrybackoff := flag.Duration("retry-backoff", 500*time.Millisecond, "base delay between retries, doubled after each attempt")
+	slowest := flag.Int("slowest", 5, "number of slowest checks to list in the summary")
+	threshold := flag.Float64("fail-threshold", 0, "percentage of checks that may fail or error without failing the run")
+	baselinefile := flag.String("baseline", "", "path to JSON or YAML file listing known failures to ignore")
+	updatebaseline := flag.Bool("update-baseline", false, "rewrite the baseline file from the current failures")
+	timeout := flag.Duration("timeout", 30*time.Second, "maximum time to wait for each request")
+	deadline := flag.Duration("deadline", 0, "maximum time for the whole run, or 0 for no limit")
+	comparelist := flag.String("compare", "", "comma-separated pair of base URLs to check the input paths against and diff")
+	watch := flag.Duration("watch", 0, "re-run checks on this interval instead of exiting after one run")
+	listen := flag.String("listen", ":9090", "address to serve /metrics and /healthz on in watch mode")
 	flag.Parse()
-	if *urlfile == "" {
+	if (len(urlfiles) == 0 && flag.NArg() == 0 && len(sitemaps) == 0 && *configfile == "" && *openapifile == "" && *harfile == "") || len(splitList(*methods)) == 0 || *concurrency < 1 || *slowest < 0 || (*updatebaseline && *baselinefile == "") {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	if *deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *deadline)
+		defer cancel()
+	}
+
+	var bases [2]string
+	if *comparelist != "" {
+		var err error
+		bases, err = parseBases(*comparelist)
+		if err != nil {
+			return exitError{code: exitUsage, err: fmt.Errorf("parse compare bases: %w", err)}
+		}
+	}
+
+	var stdin []string
+	if slices.Contains(urlfiles, "-") {
+		var err error
+		stdin = slices.Collect(scanLines(os.Stdin, &err))
+		if err != nil {
+			return fmt.Errorf("read URLs from stdin: %w", err)
+		}
+	}
+
+	origins, err := parseOrigins(*originlist, true)
+	if err != nil {
+		return fmt.Errorf("load origins: %w", err)
+	}
+	denied, err := parseOrigins(*denylist, false)
+	if err != nil {
+		return fmt.Errorf("load denied origins: %w", err)
+	}
+	origins = append(origins, denied...)
+	// With -config, the entries may supply their own origins.
+	if *preflight && len(origins) == 0 && *configfile == "" {
 		flag.Usage()
-		os.Exit(2)
+		os.Exit(exitUsage)
+	}
+	if len(origins) == 0 {
+		origins = []checkcors.Origin{{Allow: true}}
 	}
 
 	var reqheader http.Header
@@ -118,48 +331,171 @@ func run(ctx context.Context) error {
 		}
 	}
 
-	checker := Checker{
+	var config *Config
+	if *configfile != "" {
+		config, err = loadConfig(*configfile)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+	}
+
+	var baseline Baseline
+	if *baselinefile != "" {
+		baseline, err = loadBaseline(*baselinefile, *updatebaseline)
+		if err != nil {
+			return fmt.Errorf("load baseline: %w", err)
+		}
+	}
+
+	var expect map[string]checkcors.Expectation
+	if *expectfile != "" {
+		err := loadJSON(*expectfile, &expect)
+		if err != nil {
+			return fmt.Errorf("load expected headers: %w", err)
+		}
+	}
+
+	transport, err := newTransport(TransportOptions{
+		Proxy:    *proxy,
+		Insecure: *insecure,
+		CAFile:   *cafile,
+		Resolve:  resolve,
+	})
+	if err != nil {
+		return fmt.Errorf("configure transport: %w", err)
+	}
+
+	checker := checkcors.Checker{
 		Client: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: limitTransport{
+				base:  transport,
+				rate:  NewLimiter(*rate),
+				hosts: NewHostLimiter(*hostrate),
+			},
+			Timeout: *timeout,
 		},
 		ReqHeader: reqheader,
+
+		CredentialHeader: credentialHeader(*cookie, *authorization),
+		Redirects:        redirects,
+		CacheChecks:      *cachechecks,
+
+		Retries:      *retries,
+		RetryBackoff: *retrybackoff,
 	}
 
-	var wg sync.WaitGroup
-	var hadError atomic.Bool
-	var err error
-	for url := range loadURLs(*urlfile, &err) {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+	defaults := Target{
+		Origins:     origins,
+		Preflight:   *preflight,
+		Credentials: *credentials,
+		Methods:     splitList(*methods),
+		Headers:     splitList(*headers),
+		Expect:      expect,
+	}
 
-			ok, err := checker.Check(ctx, url)
-			if err != nil {
-				slog.Error("check URL", "url", url, "err", err)
-			}
-			if !ok || err != nil {
-				hadError.Store(true)
-			}
-		}()
+	var openapi []Target
+	if *openapifile != "" {
+		openapi, err = loadOpenAPI(*openapifile, *openapiserver, defaults)
+		if err != nil {
+			return fmt.Errorf("load OpenAPI document: %w", err)
+		}
 	}
+
+	var har []Target
+	if *harfile != "" {
+		har, err = loadHAR(*harfile, defaults)
+		if err != nil {
+			return fmt.Errorf("load HAR: %w", err)
+		}
+	}
+
+	discoverer := Discoverer{
+		Client:   checker.Client,
+		Sitemaps: sitemaps,
+		Crawl:    *crawl,
+		Depth:    *depth,
+		Limit:    *crawllimit,
+	}
+	targets := func(ctx context.Context, rerr *error) iter.Seq[Target] {
+		urls := loadURLs(urlfiles, flag.Args(), stdin, rerr)
+		if discoverer.Enabled() {
+			urls = discoverer.Discover(ctx, urls, rerr)
+		}
+		return config.Targets(concat(urlTargets(urls, defaults), slices.Values(openapi), slices.Values(har)), defaults)
+	}
+
+	if *comparelist != "" {
+		comparison := Comparison{Bases: bases}
+		checkAll(ctx, checker, comparison.Targets(targets(ctx, &err)), *concurrency, comparison.Report)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("load URLs: %w", err)
+		}
+		err = comparison.Print(os.Stdout)
+		if ctx.Err() != nil {
+			return incomplete(ctx)
+		}
+		return err
+	}
+
+	if *watch > 0 {
+		return runWatch(ctx, *watch, *listen, func(ctx context.Context, report func(Record)) error {
+			var err error
+			checkAll(ctx, checker, targets(ctx, &err), *concurrency, func(record Record) {
+				report(baseline.Apply(record))
+			})
+			return err
+		})
+	}
+
+	reporter, err := newReporter(*output, os.Stdout, origins)
 	if err != nil {
+		return fmt.Errorf("create reporter: %w", err)
+	}
+	if *junitfile != "" {
+		reporter = multiReporter{reporter, &junitReporter{path: *junitfile}}
+	}
+	if *updatebaseline {
+		reporter = multiReporter{reporter, &baselineWriter{path: *baselinefile}}
+	}
+
+	summary := Summary{Slowest: *slowest}
+	checkAll(ctx, checker, targets(ctx, &err), *concurrency, func(record Record) {
+		record = baseline.Apply(record)
+		reporter.Report(record)
+		summary.Report(record)
+	})
+	if err != nil && ctx.Err() == nil {
 		return fmt.Errorf("load URLs: %w", err)
 	}
 
-	wg.Wait()
-	if hadError.Load() {
-		return errors.New("unsuccessful")
+	err = reporter.Close()
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
 	}
-	return nil
+
+	summary.Print(os.Stderr)
+	if ctx.Err() != nil {
+		return incomplete(ctx)
+	}
+	if *updatebaseline {
+		slog.Info("updated baseline", "path", *baselinefile)
+		return nil
+	}
+	return summary.Err(*threshold)
 }
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	err := run(ctx)
 	if err != nil {
 		slog.Error("failed", "err", err)
-		os.Exit(1)
+
+		var exit exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		os.Exit(exitFailure)
 	}
 }
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type Comparison struct {
+	Bases [2]string
+
+	m       sync.Mutex
+	records map[string][2]*Record
+}
+
+func parseBases(list string) ([2]string, error) {
+	bases := splitList(list)
+	if len(bases) != 2 {
+		return [2]string{}, fmt.Errorf("expected exactly two base URLs, got %v", len(bases))
+	}
+	for _, base := range bases {
+		u, err := url.Parse(base)
+		if err != nil || !u.IsAbs() {
+			return [2]string{}, fmt.Errorf("invalid base URL %q", base)
+		}
+	}
+	return [2]string{strings.TrimSuffix(bases[0], "/"), strings.TrimSuffix(bases[1], "/")}, nil
+}
+
+func comparePath(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.IsAbs() {
+		return u.RequestURI()
+	}
+	if !strings.HasPrefix(raw, "/") {
+		return "/" + raw
+	}
+	return raw
+}
+
+func (c *Comparison) Targets(targets iter.Seq[Target]) iter.Seq[Target] {
+	return func(yield func(Target) bool) {
+		for t := range targets {
+			path := comparePath(t.URL)
+			for _, base := range c.Bases {
+				t.URL = base + path
+				if !yield(t) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Comparison) Report(record Record) {
+	if record.Status == StatusSkipped {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.records == nil {
+		c.records = make(map[string][2]*Record)
+	}
+
+	i := c.base(record.URL)
+	path := record
+	path.URL = strings.TrimPrefix(record.URL, c.Bases[i])
+	key := path.Label()
+
+	pair := c.records[key]
+	pair[i] = &record
+	c.records[key] = pair
+}
+
+// base returns the index of the base that url was built from,
+// preferring the longer base in case one is a prefix of the other.
+func (c *Comparison) base(url string) int {
+	if !strings.HasPrefix(url, c.Bases[0]) {
+		return 1
+	}
+	if strings.HasPrefix(url, c.Bases[1]) && len(c.Bases[1]) > len(c.Bases[0]) {
+		return 1
+	}
+	return 0
+}
+
+func (c *Comparison) Print(w io.Writer) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	keys := slices.Sorted(maps.Keys(c.records))
+
+	var diffs int
+	for _, key := range keys {
+		lines := compareRecords(c.Bases, c.records[key])
+		if len(lines) == 0 {
+			continue
+		}
+		diffs++
+
+		fmt.Fprintln(w, key)
+		for _, line := range lines {
+			fmt.Fprintf(w, "  %v\n", line)
+		}
+	}
+
+	fmt.Fprintf(w, "%v of %v compared checks differ between %v and %v\n", diffs, len(keys), c.Bases[0], c.Bases[1])
+	if diffs != 0 {
+		return exitError{
+			code: exitMismatch,
+			err:  fmt.Errorf("%v checks differ", diffs),
+		}
+	}
+	return nil
+}
+
+func compareRecords(bases [2]string, pair [2]*Record) []string {
+	for i, record := range pair {
+		if record == nil {
+			return []string{fmt.Sprintf("not checked against %v", bases[i])}
+		}
+		if record.Status == StatusError {
+			return []string{fmt.Sprintf("%v: %v", bases[i], record.Error)}
+		}
+	}
+
+	var lines []string
+	if pair[0].HTTPStatus != pair[1].HTTPStatus {
+		lines = append(lines, fmt.Sprintf("status: %v vs. %v", pair[0].HTTPStatus, pair[1].HTTPStatus))
+	}
+
+	var names []string
+	for _, record := range pair {
+		for name := range record.Header {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		v0, ok0 := headerValue(pair[0].Header, name)
+		v1, ok1 := headerValue(pair[1].Header, name)
+		switch {
+		case !ok0:
+			lines = append(lines, fmt.Sprintf("%v: only in %v: %q", name, bases[1], v1))
+		case !ok1:
+			lines = append(lines, fmt.Sprintf("%v: only in %v: %q", name, bases[0], v0))
+		case v0 != v1:
+			lines = append(lines, fmt.Sprintf("%v: %q vs. %q", name, v0, v1))
+		}
+	}
+	return lines
+}
+
+func headerValue(h http.Header, name string) (string, bool) {
+	vals, ok := h[name]
+	return strings.Join(vals, ", "), ok
+}
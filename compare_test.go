@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestComparePath(t *testing.T) {
+	tests := map[string]string{
+		"/api/x":                          "/api/x",
+		"api/x":                           "/api/x",
+		"/api/x?q=1":                      "/api/x?q=1",
+		"https://staging.example/api/x":   "/api/x",
+		"https://staging.example/a?q=1#f": "/a?q=1",
+		"https://staging.example":         "/",
+	}
+
+	for raw, want := range tests {
+		if got := comparePath(raw); got != want {
+			t.Errorf("comparePath(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestComparisonBase(t *testing.T) {
+	tests := []struct {
+		name  string
+		bases [2]string
+		url   string
+		want  int
+	}{
+		{"First", [2]string{"https://staging.example", "https://prod.example"}, "https://staging.example/x", 0},
+		{"Second", [2]string{"https://staging.example", "https://prod.example"}, "https://prod.example/x", 1},
+		{"FirstIsPrefix", [2]string{"https://api.example", "https://api.example/v2"}, "https://api.example/v2/x", 1},
+		{"FirstIsPrefixOfOther", [2]string{"https://api.example", "https://api.example/v2"}, "https://api.example/x", 0},
+		{"SecondIsPrefix", [2]string{"https://api.example/v2", "https://api.example"}, "https://api.example/v2/x", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Comparison{Bases: test.bases}
+			if got := c.base(test.url); got != test.want {
+				t.Errorf("base(%q) = %v, want %v", test.url, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseBases(t *testing.T) {
+	bases, err := parseBases("https://staging.example/, https://prod.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [2]string{"https://staging.example", "https://prod.example"}; bases != want {
+		t.Errorf("got %q, want %q", bases, want)
+	}
+
+	for _, list := range []string{"", "https://staging.example", "a,b,c", "/staging,/prod"} {
+		if _, err := parseBases(list); err == nil {
+			t.Errorf("parseBases(%q) succeeded, want error", list)
+		}
+	}
+}
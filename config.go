@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+type Target struct {
+	URL         string
+	Origins     []checkcors.Origin
+	Preflight   bool
+	Credentials bool
+	Methods     []string
+	Headers     []string
+	ContentType string
+	Expect      map[string]checkcors.Expectation
+}
+
+func (t Target) checkers(base checkcors.Checker) []checkcors.Checker {
+	base.Preflight = t.Preflight
+	base.Credentials = t.Credentials
+	base.RequestHeaders = t.Headers
+	base.ContentType = t.ContentType
+	base.Expect = t.Expect
+
+	headers := slices.Clone(t.Headers)
+	if t.Credentials {
+		for name := range base.CredentialHeader {
+			if !strings.EqualFold(name, "Cookie") {
+				headers = append(headers, name)
+			}
+		}
+	}
+
+	checkers := make([]checkcors.Checker, 0, len(t.Methods))
+	for _, method := range t.Methods {
+		c := base
+		c.Method = strings.ToUpper(method)
+		c.Preflight = t.Preflight || checkcors.NeedsPreflight(c.Method, headers, t.ContentType)
+		checkers = append(checkers, c)
+	}
+	return checkers
+}
+
+type Config struct {
+	Entries []ConfigEntry `json:"targets"`
+}
+
+type ConfigEntry struct {
+	URL         string                           `json:"url"`
+	Pattern     string                           `json:"pattern"`
+	Origins     []string                         `json:"origins"`
+	DenyOrigins []string                         `json:"deny_origins"`
+	Preflight   *bool                            `json:"preflight"`
+	Credentials *bool                            `json:"credentials"`
+	Methods     []string                         `json:"methods"`
+	Headers     []string                         `json:"headers"`
+	Expect      map[string]checkcors.Expectation `json:"expect"`
+}
+
+func loadConfig(file string) (*Config, error) {
+	var config Config
+	err := loadDoc(file, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range config.Entries {
+		if (entry.URL == "") == (entry.Pattern == "") {
+			return nil, fmt.Errorf("target %v: exactly one of url or pattern must be set", i)
+		}
+		if entry.Pattern != "" {
+			_, err := path.Match(entry.Pattern, "")
+			if err != nil {
+				return nil, fmt.Errorf("target %v: pattern %q: %w", i, entry.Pattern, err)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+func (entry ConfigEntry) Matches(rawURL string) bool {
+	if entry.URL != "" {
+		return entry.URL == rawURL
+	}
+
+	if strings.HasPrefix(entry.Pattern, "/") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return false
+		}
+		ok, _ := path.Match(entry.Pattern, u.Path)
+		return ok
+	}
+
+	ok, _ := path.Match(entry.Pattern, rawURL)
+	return ok
+}
+
+func (entry ConfigEntry) Apply(t Target) Target {
+	if entry.URL != "" {
+		t.URL = entry.URL
+	}
+
+	if len(entry.Origins) != 0 || len(entry.DenyOrigins) != 0 {
+		t.Origins = nil
+		for _, origin := range entry.Origins {
+			t.Origins = append(t.Origins, checkcors.Origin{Origin: origin, Allow: true})
+		}
+		for _, origin := range entry.DenyOrigins {
+			t.Origins = append(t.Origins, checkcors.Origin{Origin: origin, Allow: false})
+		}
+	}
+
+	if len(entry.Methods) != 0 {
+		t.Methods = entry.Methods
+	}
+	if entry.Preflight != nil {
+		t.Preflight = *entry.Preflight
+	}
+	if entry.Credentials != nil {
+		t.Credentials = *entry.Credentials
+	}
+	if len(entry.Headers) != 0 {
+		t.Headers = entry.Headers
+	}
+	if entry.Expect != nil {
+		t.Expect = entry.Expect
+	}
+
+	return t
+}
+
+func urlTargets(urls iter.Seq[string], defaults Target) iter.Seq[Target] {
+	return func(yield func(Target) bool) {
+		for url := range urls {
+			t := defaults
+			t.URL = url
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Config) Target(t Target) Target {
+	if c == nil {
+		return t
+	}
+
+	for _, entry := range c.Entries {
+		if entry.URL != "" && entry.Matches(t.URL) {
+			return entry.Apply(t)
+		}
+	}
+	for _, entry := range c.Entries {
+		if entry.Pattern != "" && entry.Matches(t.URL) {
+			return entry.Apply(t)
+		}
+	}
+	return t
+}
+
+func (c *Config) Targets(targets iter.Seq[Target], defaults Target) iter.Seq[Target] {
+	return func(yield func(Target) bool) {
+		seen := make(map[string]struct{})
+		for t := range targets {
+			seen[t.URL] = struct{}{}
+			if !yield(c.Target(t)) {
+				return
+			}
+		}
+
+		if c == nil {
+			return
+		}
+		for _, entry := range c.Entries {
+			if entry.URL == "" {
+				continue
+			}
+			if _, ok := seen[entry.URL]; ok {
+				continue
+			}
+			if !yield(entry.Apply(defaults)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+func TestConfigTargets(t *testing.T) {
+	config := &Config{Entries: []ConfigEntry{
+		{URL: "https://api.example/upload", Origins: []string{"https://upload.example"}},
+		{URL: "https://api.example/extra"},
+		{Pattern: "/admin/*", DenyOrigins: []string{"https://a.example"}},
+		{Pattern: "/admin/*", Origins: []string{"https://never.example"}},
+		{Pattern: "https://api.example/*", Origins: []string{"https://pattern.example"}},
+	}}
+	defaults := Target{Origins: []checkcors.Origin{{Origin: "https://a.example", Allow: true}}, Methods: []string{"GET"}}
+
+	input := urlTargets(slices.Values([]string{
+		"https://api.example/upload",
+		"https://api.example/admin/users",
+		"https://other.example/x",
+	}), defaults)
+	targets := slices.Collect(config.Targets(input, defaults))
+
+	want := []struct {
+		url    string
+		origin checkcors.Origin
+	}{
+		{"https://api.example/upload", checkcors.Origin{Origin: "https://upload.example", Allow: true}},
+		{"https://api.example/admin/users", checkcors.Origin{Origin: "https://a.example", Allow: false}},
+		{"https://other.example/x", checkcors.Origin{Origin: "https://a.example", Allow: true}},
+		{"https://api.example/extra", checkcors.Origin{Origin: "https://a.example", Allow: true}},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("got %v targets, want %v: %+v", len(targets), len(want), targets)
+	}
+	for i, target := range targets {
+		if target.URL != want[i].url {
+			t.Errorf("target %v: URL = %q, want %q", i, target.URL, want[i].url)
+		}
+		if len(target.Origins) != 1 || target.Origins[0] != want[i].origin {
+			t.Errorf("target %v: origins = %+v, want %+v", i, target.Origins, want[i].origin)
+		}
+	}
+}
+
+func TestNilConfigTargets(t *testing.T) {
+	var config *Config
+	defaults := Target{Methods: []string{"GET"}}
+	targets := slices.Collect(config.Targets(urlTargets(slices.Values([]string{"https://api.example/"}), defaults), defaults))
+	if len(targets) != 1 || targets[0].URL != "https://api.example/" {
+		t.Errorf("got %+v", targets)
+	}
+}
+
+func TestConfigEntryMethods(t *testing.T) {
+	entry := ConfigEntry{URL: "https://api.example/", Methods: []string{"GET", "DELETE"}}
+	target := entry.Apply(Target{Origins: []checkcors.Origin{{Origin: "https://a.example", Allow: true}}})
+
+	checkers := target.checkers(checkcors.Checker{})
+	if len(checkers) != 2 {
+		t.Fatalf("got %v checkers, want 2", len(checkers))
+	}
+	for _, c := range checkers {
+		if want := c.Method != "GET"; c.Preflight != want {
+			t.Errorf("%v: preflight = %v, want %v", c.Method, c.Preflight, want)
+		}
+	}
+}
+
+func TestTargetCheckersCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		preflight bool
+	}{
+		{"None", nil, false},
+		{"Cookie", credentialHeader("sid=1", ""), false},
+		{"Authorization", credentialHeader("", "Bearer x"), true},
+		{"Both", credentialHeader("sid=1", "Bearer x"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := Target{Credentials: true, Methods: []string{"GET"}}
+			checkers := target.checkers(checkcors.Checker{CredentialHeader: test.header})
+			if len(checkers) != 1 {
+				t.Fatalf("got %v checkers, want 1", len(checkers))
+			}
+			if checkers[0].Preflight != test.preflight {
+				t.Errorf("preflight = %v, want %v", checkers[0].Preflight, test.preflight)
+			}
+		})
+	}
+}
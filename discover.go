@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+)
+
+const maxDiscoverBody = 10 << 20
+
+type sitemapDoc struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+type Discoverer struct {
+	Client   *http.Client
+	Sitemaps []string
+	Crawl    bool
+	Depth    int
+	Limit    int
+}
+
+func (d Discoverer) Enabled() bool {
+	return len(d.Sitemaps) != 0 || d.Crawl
+}
+
+func (d Discoverer) Discover(ctx context.Context, seeds iter.Seq[string], rerr *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		urls := slices.Collect(seeds)
+		if *rerr != nil {
+			return
+		}
+
+		seen := make(map[string]struct{})
+		for _, sitemap := range d.Sitemaps {
+			found, err := d.sitemap(ctx, sitemap, seen)
+			if err != nil {
+				*rerr = fmt.Errorf("load sitemap %v: %w", sitemap, err)
+				return
+			}
+			urls = append(urls, found...)
+		}
+
+		if d.Crawl {
+			urls = d.crawl(ctx, urls)
+		}
+
+		done := make(map[string]struct{}, len(urls))
+		for _, u := range urls {
+			if _, ok := done[u]; ok {
+				continue
+			}
+			done[u] = struct{}{}
+
+			if !yield(u) {
+				return
+			}
+		}
+	}
+}
+
+func (d Discoverer) sitemap(ctx context.Context, loc string, seen map[string]struct{}) ([]string, error) {
+	if _, ok := seen[loc]; ok {
+		return nil, nil
+	}
+	seen[loc] = struct{}{}
+
+	body, _, err := d.fetch(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc sitemapDoc
+	err = xml.Unmarshal(body, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	var urls []string
+	for _, u := range doc.URLs {
+		urls = append(urls, u.Loc)
+	}
+	for _, s := range doc.Sitemaps {
+		found, err := d.sitemap(ctx, s.Loc, seen)
+		if err != nil {
+			return nil, fmt.Errorf("load sitemap %v: %w", s.Loc, err)
+		}
+		urls = append(urls, found...)
+	}
+	return urls, nil
+}
+
+var linkRE = regexp.MustCompile(`(?i)<a\s[^>]*?href\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+func (d Discoverer) crawl(ctx context.Context, seeds []string) []string {
+	type page struct {
+		url   *url.URL
+		depth int
+	}
+
+	var queue []page
+	seen := make(map[string]struct{})
+	var urls []string
+	var found int
+	add := func(u *url.URL, depth int) {
+		u.Fragment = ""
+		key := u.String()
+		if _, ok := seen[key]; ok {
+			return
+		}
+		if depth > 0 {
+			if found >= d.Limit {
+				return
+			}
+			found++
+		}
+		seen[key] = struct{}{}
+		urls = append(urls, key)
+		queue = append(queue, page{url: u, depth: depth})
+	}
+
+	for _, seed := range seeds {
+		u, err := url.Parse(seed)
+		if err != nil {
+			slog.Warn("skip invalid URL", "url", seed, "err", err)
+			continue
+		}
+		add(u, 0)
+	}
+
+	for len(queue) != 0 && ctx.Err() == nil {
+		p := queue[0]
+		queue = queue[1:]
+		if p.depth >= d.Depth {
+			continue
+		}
+
+		body, ctype, err := d.fetch(ctx, p.url.String())
+		if err != nil {
+			slog.Warn("crawl page", "url", p.url, "err", err)
+			continue
+		}
+		if mt, _, _ := mime.ParseMediaType(ctype); mt != "text/html" {
+			continue
+		}
+
+		for _, m := range linkRE.FindAllSubmatch(body, -1) {
+			href := string(m[1]) + string(m[2]) + string(m[3])
+			link, err := p.url.Parse(href)
+			if err != nil || link.Scheme != p.url.Scheme || link.Host != p.url.Host {
+				continue
+			}
+			add(link, p.depth+1)
+		}
+	}
+
+	return urls
+}
+
+func (d Discoverer) fetch(ctx context.Context, loc string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", loc, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("create request: %w", err)
+	}
+
+	rsp, err := d.Client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("perform request: %w", err)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("unexpected status %v", rsp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, maxDiscoverBody))
+	if err != nil {
+		return nil, "", fmt.Errorf("read body: %w", err)
+	}
+	return body, rsp.Header.Get("Content-Type"), nil
+}
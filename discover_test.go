@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/html")
+		io.WriteString(rw, `<a href="/c">c</a> <a href='/d#top'>d</a> <a href="https://other.example/">other</a>`)
+	})
+	mux.HandleFunc("/b", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/html")
+		io.WriteString(rw, `<a href="/a">a</a>`)
+	})
+	mux.HandleFunc("/c", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/html")
+		io.WriteString(rw, `<a href="/e">e</a>`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCrawl(t *testing.T) {
+	server := newTestSite(t)
+	d := Discoverer{Client: server.Client(), Crawl: true, Depth: 2, Limit: 100}
+
+	got := d.crawl(context.Background(), []string{server.URL + "/a"})
+	want := []string{server.URL + "/a", server.URL + "/c", server.URL + "/d", server.URL + "/e"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCrawlLimitKeepsSeeds(t *testing.T) {
+	server := newTestSite(t)
+	d := Discoverer{Client: server.Client(), Crawl: true, Depth: 2, Limit: 1}
+
+	got := d.crawl(context.Background(), []string{server.URL + "/a", server.URL + "/b"})
+	want := []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+type harDoc struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harIgnoredHeaders are headers that the browser sets on its own and
+// that a page can't ask permission to send.
+var harIgnoredHeaders = []string{
+	"accept-charset",
+	"accept-encoding",
+	"connection",
+	"content-length",
+	"cookie",
+	"date",
+	"dnt",
+	"expect",
+	"host",
+	"keep-alive",
+	"origin",
+	"priority",
+	"referer",
+	"te",
+	"trailer",
+	"transfer-encoding",
+	"upgrade",
+	"user-agent",
+	"via",
+}
+
+func loadHAR(path string, defaults Target) ([]Target, error) {
+	var doc harDoc
+	err := loadJSON(path, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	seen := make(map[string]int)
+	for _, entry := range doc.Log.Entries {
+		req := entry.Request
+		method := strings.ToUpper(req.Method)
+
+		var origin, contentType, requestMethod, requestHeaders string
+		var headers []string
+		var credentials bool
+		for _, h := range req.Headers {
+			name := strings.ToLower(h.Name)
+			switch name {
+			case "origin":
+				origin = h.Value
+			case "cookie":
+				credentials = true
+			case "content-type":
+				contentType = h.Value
+			case "access-control-request-method":
+				requestMethod = h.Value
+			case "access-control-request-headers":
+				requestHeaders = h.Value
+			}
+
+			if name == "content-type" || strings.HasPrefix(name, ":") || strings.HasPrefix(name, "sec-") || strings.HasPrefix(name, "proxy-") || strings.HasPrefix(name, "access-control-") || slices.Contains(harIgnoredHeaders, name) {
+				continue
+			}
+			headers = append(headers, name)
+		}
+		if !isCrossOrigin(origin, req.URL) {
+			continue
+		}
+
+		t := defaults
+		t.URL = req.URL
+		t.Origins = []checkcors.Origin{{Origin: origin, Allow: harAllow(defaults.Origins, origin)}}
+		t.Credentials = t.Credentials || credentials
+		t.Methods = []string{method}
+		t.Headers = headers
+		t.ContentType = contentType
+		if method == "OPTIONS" && requestMethod != "" {
+			// The value of a requested Content-Type isn't recorded in a
+			// preflight, so it stays in Headers and is merged with the
+			// actual request below if there is one.
+			t.Methods = []string{strings.ToUpper(requestMethod)}
+			t.Headers = parseHeaderList(requestHeaders)
+			t.ContentType = ""
+			t.Preflight = true
+		}
+		slices.Sort(t.Headers)
+		t.Headers = slices.Compact(t.Headers)
+
+		key := fmt.Sprint(t.URL, "\x00", origin, "\x00", t.Methods[0], "\x00", slices.DeleteFunc(slices.Clone(t.Headers), isContentType))
+		if i, ok := seen[key]; ok {
+			merged := &targets[i]
+			merged.Preflight = merged.Preflight || t.Preflight
+			merged.ContentType = cmp.Or(merged.ContentType, t.ContentType)
+			merged.Headers = slices.Compact(slices.Sorted(slices.Values(append(merged.Headers, t.Headers...))))
+			continue
+		}
+		seen[key] = len(targets)
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
+
+func isCrossOrigin(origin, rawURL string) bool {
+	if origin == "" {
+		return false
+	}
+	if origin == "null" {
+		return true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return !strings.EqualFold(origin, u.Scheme+"://"+u.Host)
+}
+
+// harAllow reports whether a recorded origin is expected to be
+// allowed. Origins are assumed to be allowed unless they were
+// explicitly denied.
+func harAllow(origins []checkcors.Origin, origin string) bool {
+	return !slices.ContainsFunc(origins, func(o checkcors.Origin) bool {
+		return !o.Allow && o.Origin == origin
+	})
+}
+
+func parseHeaderList(list string) []string {
+	names := splitList(list)
+	for i, name := range names {
+		names[i] = strings.ToLower(name)
+	}
+	return names
+}
+
+func isContentType(name string) bool {
+	return name == "content-type"
+}
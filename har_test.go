@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+const testHAR = `{"log": {"entries": [
+	{"request": {"method": "OPTIONS", "url": "https://api.example/json", "headers": [
+		{"name": "Origin", "value": "https://app.example"},
+		{"name": "Access-Control-Request-Method", "value": "POST"},
+		{"name": "Access-Control-Request-Headers", "value": "content-type,X-Token"}
+	]}},
+	{"request": {"method": "POST", "url": "https://api.example/json", "headers": [
+		{"name": "Origin", "value": "https://app.example"},
+		{"name": "Content-Type", "value": "application/json"},
+		{"name": "X-Token", "value": "1"},
+		{"name": "User-Agent", "value": "test"},
+		{"name": ":authority", "value": "api.example"}
+	]}},
+	{"request": {"method": "POST", "url": "https://api.example/form", "headers": [
+		{"name": "Origin", "value": "https://app.example"},
+		{"name": "Content-Type", "value": "text/plain;charset=UTF-8"},
+		{"name": "Cookie", "value": "sid=1"}
+	]}},
+	{"request": {"method": "POST", "url": "https://api.example/form", "headers": [
+		{"name": "Origin", "value": "https://app.example"},
+		{"name": "Content-Type", "value": "text/plain;charset=UTF-8"}
+	]}},
+	{"request": {"method": "GET", "url": "https://api.example/same", "headers": [
+		{"name": "Origin", "value": "https://api.example"}
+	]}},
+	{"request": {"method": "GET", "url": "https://api.example/none", "headers": []}}
+]}}`
+
+func TestLoadHAR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.har")
+	err := os.WriteFile(path, []byte(testHAR), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := loadHAR(path, Target{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("got %v targets, want 2: %+v", len(targets), targets)
+	}
+
+	json := targets[0]
+	if json.URL != "https://api.example/json" || !slices.Equal(json.Methods, []string{"POST"}) {
+		t.Errorf("json: url = %q, methods = %q", json.URL, json.Methods)
+	}
+	if json.ContentType != "application/json" {
+		t.Errorf("json: content type = %q, want application/json", json.ContentType)
+	}
+	if !slices.Equal(json.Headers, []string{"content-type", "x-token"}) {
+		t.Errorf("json: headers = %q", json.Headers)
+	}
+	if want := []checkcors.Origin{{Origin: "https://app.example", Allow: true}}; !slices.Equal(json.Origins, want) {
+		t.Errorf("json: origins = %+v, want %+v", json.Origins, want)
+	}
+	if json.Credentials {
+		t.Error("json: unexpectedly credentialed")
+	}
+	checkers := json.checkers(checkcors.Checker{})
+	if len(checkers) != 1 || !checkers[0].Preflight || checkers[0].ContentType != "application/json" {
+		t.Errorf("json: should be checked with a preflight for application/json: %+v", checkers)
+	}
+
+	form := targets[1]
+	if form.URL != "https://api.example/form" || !form.Credentials {
+		t.Errorf("form: url = %q, credentials = %v", form.URL, form.Credentials)
+	}
+	checkers = form.checkers(checkcors.Checker{})
+	if len(checkers) != 1 || checkers[0].Preflight {
+		t.Errorf("text/plain POST should be checked without a preflight: %+v", checkers)
+	}
+}
+
+func TestLoadHARDenied(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.har")
+	err := os.WriteFile(path, []byte(testHAR), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := loadHAR(path, Target{Origins: []checkcors.Origin{{Origin: "https://app.example", Allow: false}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range targets {
+		if target.Origins[0].Allow {
+			t.Errorf("%v: origin should be expected to be denied", target.URL)
+		}
+	}
+}
+
+func TestIsCrossOrigin(t *testing.T) {
+	tests := []struct {
+		origin, url string
+		want        bool
+	}{
+		{"", "https://api.example/", false},
+		{"https://api.example", "https://api.example/x", false},
+		{"https://app.example", "https://api.example/x", true},
+		{"http://api.example", "https://api.example/x", true},
+		{"null", "https://api.example/x", true},
+	}
+
+	for _, test := range tests {
+		if got := isCrossOrigin(test.origin, test.url); got != test.want {
+			t.Errorf("isCrossOrigin(%q, %q) = %v, want %v", test.origin, test.url, got, test.want)
+		}
+	}
+}
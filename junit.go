@@ -0,0 +1,156 @@
+package main
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitReporter struct {
+	path    string
+	m       sync.Mutex
+	records []Record
+}
+
+func (r *junitReporter) Report(record Record) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.records = append(r.records, record)
+}
+
+func (r *junitReporter) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	suite := junitTestSuite{Name: "checkcors"}
+	var total float64
+	for _, record := range r.records {
+		suite.Cases = append(suite.Cases, junitCase(record))
+		suite.Tests++
+		total += record.DurationMS
+		switch record.Status {
+		case StatusFail:
+			suite.Failures++
+		case StatusError:
+			suite.Errors++
+		case StatusIgnored, StatusSkipped:
+			suite.Skipped++
+		}
+	}
+	suite.Time = junitTime(total)
+	slices.SortFunc(suite.Cases, func(c1, c2 junitTestCase) int {
+		return cmp.Or(strings.Compare(c1.ClassName, c2.ClassName), strings.Compare(c1.Name, c2.Name))
+	})
+
+	file, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(xml.Header)
+	if err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(file)
+	e.Indent("", "  ")
+	err = e.Encode(junitTestSuites{Suites: []junitTestSuite{suite}})
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func junitCase(record Record) junitTestCase {
+	classname := "checkcors"
+	if u, err := url.Parse(record.URL); err == nil && u.Host != "" {
+		classname = u.Host
+	}
+
+	tc := junitTestCase{
+		Name:      record.Label(),
+		ClassName: classname,
+		Time:      junitTime(record.DurationMS),
+	}
+
+	switch record.Status {
+	case StatusIgnored:
+		tc.Skipped = &junitProblem{Message: "known failure listed in baseline"}
+
+	case StatusSkipped:
+		tc.Skipped = &junitProblem{Message: "run interrupted before check completed"}
+
+	case StatusError:
+		tc.Error = &junitProblem{
+			Message: record.Error,
+			Type:    "error",
+			Text:    record.Error,
+		}
+
+	case StatusFail:
+		if !record.Expected {
+			tc.Failure = &junitProblem{
+				Message: "origin unexpectedly allowed",
+				Type:    "allowed",
+				Text:    fmt.Sprintf("origin %v was expected to be denied", record.Origin),
+			}
+			break
+		}
+
+		var text strings.Builder
+		for _, v := range record.Checks {
+			if !v.OK {
+				fmt.Fprintf(&text, "%v: %v\n", v.Rule, v.Detail)
+			}
+		}
+		tc.Failure = &junitProblem{
+			Message: "CORS check failed",
+			Type:    "mismatch",
+			Text:    text.String(),
+		}
+	}
+
+	return tc
+}
+
+func junitTime(ms float64) string {
+	return fmt.Sprintf("%.3f", ms/1000)
+}
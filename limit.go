@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Limiter struct {
+	interval time.Duration
+
+	m    sync.Mutex
+	next time.Time
+}
+
+func NewLimiter(rate float64) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.m.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.m.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type HostLimiter struct {
+	rate float64
+
+	m     sync.Mutex
+	hosts map[string]*Limiter
+}
+
+func NewHostLimiter(rate float64) *HostLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &HostLimiter{rate: rate, hosts: make(map[string]*Limiter)}
+}
+
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.m.Lock()
+	limiter, ok := l.hosts[host]
+	if !ok {
+		limiter = NewLimiter(l.rate)
+		l.hosts[host] = limiter
+	}
+	l.m.Unlock()
+
+	return limiter.Wait(ctx)
+}
+
+type limitTransport struct {
+	base  http.RoundTripper
+	rate  *Limiter
+	hosts *HostLimiter
+}
+
+func (t limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.rate.Wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.hosts.Wait(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
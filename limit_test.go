@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterDisabled(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		if l := NewLimiter(rate); l != nil {
+			t.Errorf("NewLimiter(%v) = %+v, want nil", rate, l)
+		}
+		if l := NewHostLimiter(rate); l != nil {
+			t.Errorf("NewHostLimiter(%v) = %+v, want nil", rate, l)
+		}
+	}
+
+	var l *Limiter
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("nil limiter: %v", err)
+	}
+	var hl *HostLimiter
+	if err := hl.Wait(context.Background(), "a.example"); err != nil {
+		t.Errorf("nil host limiter: %v", err)
+	}
+}
+
+func TestLimiterSpacing(t *testing.T) {
+	l := NewLimiter(50)
+
+	start := time.Now()
+	for range 5 {
+		err := l.Wait(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first call doesn't wait, so five calls take at least four
+	// intervals of 20ms.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("5 waits at 50/s took %v, want at least 80ms", elapsed)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := NewLimiter(0.1)
+	err := l.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = l.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestHostLimiterPerHost(t *testing.T) {
+	l := NewHostLimiter(0.1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, host := range []string{"a.example", "b.example", "c.example"} {
+		err := l.Wait(ctx, host)
+		if err != nil {
+			t.Fatalf("first request to %v waited: %v", host, err)
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := l.Wait(ctx, "a.example")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second request to a.example: err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
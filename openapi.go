@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+type openAPIDoc struct {
+	Swagger  string   `json:"swagger"`
+	Host     string   `json:"host"`
+	BasePath string   `json:"basePath"`
+	Schemes  []string `json:"schemes"`
+	Servers  []struct {
+		URL       string `json:"url"`
+		Variables map[string]struct {
+			Default string `json:"default"`
+		} `json:"variables"`
+	} `json:"servers"`
+	Consumes   []string                              `json:"consumes"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters    map[string]openAPIParameter   `json:"parameters"`
+		RequestBodies map[string]openAPIRequestBody `json:"requestBodies"`
+	} `json:"components"`
+	Parameters map[string]openAPIParameter `json:"parameters"`
+}
+
+type openAPIOperation struct {
+	Parameters  []openAPIParameter  `json:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody"`
+	Consumes    []string            `json:"consumes"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                     `json:"$ref"`
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+type openAPIParameter struct {
+	Ref     string         `json:"$ref"`
+	Name    string         `json:"name"`
+	In      string         `json:"in"`
+	Example any            `json:"example"`
+	Default any            `json:"default"`
+	Type    string         `json:"type"`
+	Schema  *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Type    string `json:"type"`
+	Example any    `json:"example"`
+	Default any    `json:"default"`
+	Enum    []any  `json:"enum"`
+}
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "patch", "head"}
+
+var pathParamRE = regexp.MustCompile(`\{([^}]+)\}`)
+
+func loadOpenAPI(path, server string, defaults Target) ([]Target, error) {
+	var doc openAPIDoc
+	err := loadDoc(path, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if server == "" {
+		server, err = doc.server()
+		if err != nil {
+			return nil, err
+		}
+	}
+	server = strings.TrimSuffix(server, "/")
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	var targets []Target
+	for _, p := range paths {
+		item := doc.Paths[p]
+
+		var shared []openAPIParameter
+		if raw, ok := item["parameters"]; ok {
+			err := json.Unmarshal(raw, &shared)
+			if err != nil {
+				return nil, fmt.Errorf("path %v: parameters: %w", p, err)
+			}
+		}
+
+		for _, method := range openAPIMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+
+			var op openAPIOperation
+			err := json.Unmarshal(raw, &op)
+			if err != nil {
+				return nil, fmt.Errorf("path %v: %v: %w", p, method, err)
+			}
+			params := doc.resolve(append(slices.Clip(shared), op.Parameters...))
+
+			t := defaults
+			t.URL = server + expandPath(p, params)
+			t.Methods = []string{strings.ToUpper(method)}
+			t.Headers = slices.Clone(defaults.Headers)
+			t.ContentType = doc.contentType(op, params)
+			for _, param := range params {
+				if param.In == "header" {
+					t.Headers = append(t.Headers, param.Name)
+				}
+			}
+			targets = append(targets, t)
+		}
+	}
+
+	return targets, nil
+}
+
+func (doc *openAPIDoc) server() (string, error) {
+	if doc.Swagger != "" {
+		if doc.Host == "" {
+			return "", errors.New("document has no host; use -openapi-server")
+		}
+		scheme := "https"
+		if len(doc.Schemes) != 0 {
+			scheme = doc.Schemes[0]
+		}
+		return scheme + "://" + doc.Host + doc.BasePath, nil
+	}
+
+	if len(doc.Servers) == 0 {
+		return "", errors.New("document has no servers; use -openapi-server")
+	}
+	server := doc.Servers[0]
+	u := pathParamRE.ReplaceAllStringFunc(server.URL, func(m string) string {
+		return server.Variables[m[1:len(m)-1]].Default
+	})
+	if parsed, err := url.Parse(u); err != nil || !parsed.IsAbs() {
+		return "", fmt.Errorf("server URL %q is not absolute; use -openapi-server", u)
+	}
+	return u, nil
+}
+
+func (doc *openAPIDoc) resolve(params []openAPIParameter) []openAPIParameter {
+	resolved := make([]openAPIParameter, 0, len(params))
+	for _, param := range params {
+		if name, ok := strings.CutPrefix(param.Ref, "#/components/parameters/"); ok {
+			param = doc.Components.Parameters[name]
+		} else if name, ok := strings.CutPrefix(param.Ref, "#/parameters/"); ok {
+			param = doc.Parameters[name]
+		}
+		resolved = append(resolved, param)
+	}
+	return resolved
+}
+
+// contentType returns the media type that a browser would most likely
+// send the operation's request body with. If the operation accepts
+// several, one that requires a preflight is preferred so that the
+// check isn't more lenient than the client might be.
+func (doc *openAPIDoc) contentType(op openAPIOperation, params []openAPIParameter) string {
+	var types []string
+	switch {
+	case op.RequestBody != nil:
+		body := *op.RequestBody
+		if name, ok := strings.CutPrefix(body.Ref, "#/components/requestBodies/"); ok {
+			body = doc.Components.RequestBodies[name]
+		}
+		types = slices.Sorted(maps.Keys(body.Content))
+
+	case slices.ContainsFunc(params, func(param openAPIParameter) bool {
+		return param.In == "body" || param.In == "formData"
+	}):
+		types = op.Consumes
+		if len(types) == 0 {
+			types = doc.Consumes
+		}
+	}
+
+	types = slices.DeleteFunc(slices.Clone(types), func(t string) bool { return strings.Contains(t, "*") })
+	if i := slices.IndexFunc(types, func(t string) bool { return checkcors.NeedsPreflight("GET", nil, t) }); i >= 0 {
+		return types[i]
+	}
+	if len(types) != 0 {
+		return types[0]
+	}
+	return ""
+}
+
+func expandPath(p string, params []openAPIParameter) string {
+	return pathParamRE.ReplaceAllStringFunc(p, func(m string) string {
+		name := m[1 : len(m)-1]
+		i := slices.IndexFunc(params, func(param openAPIParameter) bool {
+			return param.In == "path" && param.Name == name
+		})
+		if i < 0 {
+			return "1"
+		}
+		return url.PathEscape(params[i].exampleValue())
+	})
+}
+
+func (param openAPIParameter) exampleValue() string {
+	candidates := []any{param.Example, param.Default}
+	typ := param.Type
+	if param.Schema != nil {
+		candidates = append(candidates, param.Schema.Example, param.Schema.Default)
+		if len(param.Schema.Enum) != 0 {
+			candidates = append(candidates, param.Schema.Enum[0])
+		}
+		typ = param.Schema.Type
+	}
+
+	for _, c := range candidates {
+		if c != nil {
+			return fmt.Sprint(c)
+		}
+	}
+
+	switch typ {
+	case "string":
+		return "example"
+	case "boolean":
+		return "true"
+	default:
+		return "1"
+	}
+}
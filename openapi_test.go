@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+const testOpenAPI = `{
+	"openapi": "3.0.0",
+	"servers": [{"url": "https://api.example/v1"}],
+	"paths": {
+		"/items/{id}": {
+			"parameters": [{"name": "id", "in": "path", "example": 7}],
+			"get": {},
+			"delete": {"parameters": [{"name": "X-Token", "in": "header"}]}
+		}
+	}
+}`
+
+func loadTestOpenAPI(t *testing.T, doc string) []Target {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	err := os.WriteFile(path, []byte(doc), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := loadOpenAPI(path, "", Target{Origins: []checkcors.Origin{{Origin: "https://a.example", Allow: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return targets
+}
+
+func TestLoadOpenAPI(t *testing.T) {
+	targets := loadTestOpenAPI(t, testOpenAPI)
+	if len(targets) != 2 {
+		t.Fatalf("got %v targets, want 2: %+v", len(targets), targets)
+	}
+
+	get, del := targets[0], targets[1]
+	for _, target := range targets {
+		if target.URL != "https://api.example/v1/items/7" {
+			t.Errorf("URL = %q", target.URL)
+		}
+	}
+	if !slices.Equal(get.Methods, []string{"GET"}) || get.Preflight {
+		t.Errorf("get: methods = %q, preflight = %v, want a simple GET", get.Methods, get.Preflight)
+	}
+	if !slices.Equal(del.Methods, []string{"DELETE"}) || !slices.Contains(del.Headers, "X-Token") {
+		t.Errorf("delete: methods = %q, headers = %q", del.Methods, del.Headers)
+	}
+
+	checkers := get.checkers(checkcors.Checker{})
+	if len(checkers) != 1 || checkers[0].Preflight {
+		t.Errorf("GET operation should be checked without a preflight: %+v", checkers)
+	}
+}
+
+func TestLoadOpenAPIContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		contentType string
+		preflight   bool
+	}{
+		{
+			name: "JSON",
+			doc: `{"openapi": "3.0.0", "servers": [{"url": "https://api.example"}], "paths": {"/items": {"post": {
+				"requestBody": {"content": {"application/json": {}, "application/x-www-form-urlencoded": {}}}
+			}}}}`,
+			contentType: "application/json",
+			preflight:   true,
+		},
+		{
+			name: "Form",
+			doc: `{"openapi": "3.0.0", "servers": [{"url": "https://api.example"}], "paths": {"/items": {"post": {
+				"requestBody": {"content": {"application/x-www-form-urlencoded": {}, "*/*": {}}}
+			}}}}`,
+			contentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name: "Ref",
+			doc: `{"openapi": "3.0.0", "servers": [{"url": "https://api.example"}], "paths": {"/items": {"post": {
+				"requestBody": {"$ref": "#/components/requestBodies/Item"}
+			}}}, "components": {"requestBodies": {"Item": {"content": {"application/json": {}}}}}}`,
+			contentType: "application/json",
+			preflight:   true,
+		},
+		{
+			name: "Swagger",
+			doc: `{"swagger": "2.0", "host": "api.example", "consumes": ["application/json"], "paths": {"/items": {"post": {
+				"parameters": [{"name": "body", "in": "body"}]
+			}}}}`,
+			contentType: "application/json",
+			preflight:   true,
+		},
+		{
+			name: "SwaggerOperation",
+			doc: `{"swagger": "2.0", "host": "api.example", "consumes": ["application/json"], "paths": {"/items": {"post": {
+				"consumes": ["multipart/form-data"],
+				"parameters": [{"name": "file", "in": "formData"}]
+			}}}}`,
+			contentType: "multipart/form-data",
+		},
+		{
+			name: "SwaggerNoBody",
+			doc:  `{"swagger": "2.0", "host": "api.example", "consumes": ["application/json"], "paths": {"/items": {"post": {}}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets := loadTestOpenAPI(t, test.doc)
+			if len(targets) != 1 {
+				t.Fatalf("got %v targets, want 1: %+v", len(targets), targets)
+			}
+			if targets[0].ContentType != test.contentType {
+				t.Errorf("content type = %q, want %q", targets[0].ContentType, test.contentType)
+			}
+
+			checkers := targets[0].checkers(checkcors.Checker{})
+			if len(checkers) != 1 || checkers[0].Preflight != test.preflight {
+				t.Errorf("checkers = %+v, want preflight = %v", checkers, test.preflight)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+func parseOrigins(list string, allow bool) ([]checkcors.Origin, error) {
+	var origins []checkcors.Origin
+	if path, ok := strings.CutPrefix(list, "@"); ok {
+		var err error
+		for line := range loadLines(path, &err) {
+			origins = append(origins, checkcors.Origin{Origin: strings.TrimSpace(line), Allow: allow})
+		}
+		return origins, err
+	}
+
+	for _, origin := range strings.Split(list, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin != "" {
+			origins = append(origins, checkcors.Origin{Origin: origin, Allow: allow})
+		}
+	}
+	return origins, nil
+}
+
+type Matrix struct {
+	Origins []checkcors.Origin
+
+	m     sync.Mutex
+	rows  []string
+	extra []string
+	cells map[string]map[string]string
+}
+
+func (m *Matrix) Add(record Record) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if m.cells == nil {
+		m.cells = make(map[string]map[string]string)
+	}
+	name := record.Name()
+	if !slices.Contains(m.rows, name) {
+		m.rows = append(m.rows, name)
+	}
+	known := slices.ContainsFunc(m.Origins, func(origin checkcors.Origin) bool { return origin.Origin == record.Origin })
+	if !known && !slices.Contains(m.extra, record.Origin) {
+		m.extra = append(m.extra, record.Origin)
+	}
+
+	row, ok := m.cells[name]
+	if !ok {
+		row = make(map[string]string)
+		m.cells[name] = row
+	}
+	row[record.Origin] = matrixCell(record)
+}
+
+func matrixCell(record Record) string {
+	switch record.Status {
+	case StatusError:
+		return "error"
+	case StatusIgnored:
+		return "ignored"
+	case StatusSkipped:
+		return "skipped"
+	}
+
+	if record.Status == StatusFail && record.Allowed == record.Expected {
+		return "allowed (checks failed)"
+	}
+	if record.Status == StatusFail {
+		if record.Allowed {
+			return "allowed (want denied)"
+		}
+		return "denied (want allowed)"
+	}
+	if record.Allowed {
+		return "allowed"
+	}
+	return "denied"
+}
+
+func (m *Matrix) Print(w io.Writer) error {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	var columns []string
+	for _, origin := range m.Origins {
+		if !slices.Contains(columns, origin.Origin) {
+			columns = append(columns, origin.Origin)
+		}
+	}
+	slices.Sort(m.extra)
+	columns = append(columns, m.extra...)
+	if !slices.ContainsFunc(columns, func(origin string) bool { return origin != "" }) {
+		return nil
+	}
+
+	slices.Sort(m.rows)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprint(tw, "URL")
+	for _, origin := range columns {
+		fmt.Fprintf(tw, "\t%v", cmp.Or(origin, "(none)"))
+	}
+	fmt.Fprintln(tw)
+
+	for _, name := range m.rows {
+		fmt.Fprint(tw, name)
+		for _, origin := range columns {
+			fmt.Fprintf(tw, "\t%v", cmp.Or(m.cells[name][origin], "-"))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
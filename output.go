@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"deedles.dev/checkcors/pkg/checkcors"
+)
+
+type Status string
+
+const (
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusError   Status = "error"
+	StatusIgnored Status = "ignored"
+	StatusSkipped Status = "skipped"
+)
+
+type Record struct {
+	URL        string              `json:"url"`
+	Origin     string              `json:"origin,omitempty"`
+	Method     string              `json:"method"`
+	Preflight  bool                `json:"preflight"`
+	Status     Status              `json:"status"`
+	Allowed    bool                `json:"allowed"`
+	Expected   bool                `json:"expected_allowed"`
+	HTTPStatus int                 `json:"http_status,omitempty"`
+	Header     http.Header         `json:"headers,omitempty"`
+	Checks     []checkcors.Verdict `json:"checks"`
+	Redirects  []checkcors.Hop     `json:"redirects,omitempty"`
+	Attempts   int                 `json:"attempts"`
+	DurationMS float64             `json:"duration_ms"`
+	Error      string              `json:"error,omitempty"`
+}
+
+func newRecord(result checkcors.Result, err error) Record {
+	record := Record{
+		URL:        result.URL,
+		Origin:     result.Origin.Origin,
+		Method:     result.Method,
+		Preflight:  result.Preflight,
+		Allowed:    result.Allowed(),
+		Expected:   result.Origin.Allow,
+		HTTPStatus: result.Status,
+		Header:     checkcors.CORSHeaders(result.Header),
+		Checks:     result.Verdicts,
+		Redirects:  result.Redirects,
+		Attempts:   result.Attempts,
+		DurationMS: float64(result.Duration) / float64(time.Millisecond),
+	}
+	if record.Checks == nil {
+		record.Checks = []checkcors.Verdict{}
+	}
+
+	switch {
+	case err != nil:
+		record.Status = StatusError
+		record.Allowed = false
+		record.Error = err.Error()
+	case result.Passed():
+		record.Status = StatusPass
+	default:
+		record.Status = StatusFail
+	}
+	return record
+}
+
+func skippedRecord(result checkcors.Result, err error) Record {
+	record := newRecord(result, err)
+	record.Status = StatusSkipped
+	return record
+}
+
+func (r Record) Name() string {
+	name := r.URL
+	if r.Preflight || r.Method != "GET" {
+		name = r.Method + " " + name
+	}
+	return name
+}
+
+func (r Record) Label() string {
+	if r.Origin == "" {
+		return r.Name()
+	}
+	return r.Name() + " [" + r.Origin + "]"
+}
+
+type Reporter interface {
+	Report(Record)
+	Close() error
+}
+
+func newReporter(format string, w io.Writer, origins []checkcors.Origin) (Reporter, error) {
+	switch format {
+	case "log":
+		return &logReporter{matrix: &Matrix{Origins: origins}, w: w}, nil
+	case "json":
+		return &jsonReporter{w: w, records: []Record{}}, nil
+	case "ndjson":
+		return &ndjsonReporter{e: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+type multiReporter []Reporter
+
+func (r multiReporter) Report(record Record) {
+	for _, reporter := range r {
+		reporter.Report(record)
+	}
+}
+
+func (r multiReporter) Close() error {
+	var errs []error
+	for _, reporter := range r {
+		errs = append(errs, reporter.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type logReporter struct {
+	matrix *Matrix
+	w      io.Writer
+}
+
+func (r *logReporter) Report(record Record) {
+	if r.matrix != nil {
+		r.matrix.Add(record)
+	}
+
+	slog := slog.With("url", record.URL)
+	if record.Preflight || record.Method != "GET" {
+		slog = slog.With("method", record.Method)
+	}
+	if record.Origin != "" {
+		slog = slog.With("origin", record.Origin)
+	}
+
+	if record.Status == StatusIgnored {
+		slog.Info("ignoring known failure")
+		return
+	}
+	if record.Status == StatusSkipped {
+		return
+	}
+
+	if record.Status == StatusError {
+		slog.Error("check URL", "err", record.Error)
+		return
+	}
+
+	if !record.Expected {
+		if record.Allowed {
+			slog.Error("origin unexpectedly allowed")
+		}
+		return
+	}
+
+	for _, v := range record.Checks {
+		if !v.OK {
+			slog.Error("check failed", "rule", v.Rule, "detail", v.Detail)
+		}
+	}
+
+	if record.Preflight {
+		if !record.Allowed {
+			slog.Error("preflight would fail")
+			return
+		}
+		slog.Info("preflight would succeed")
+	}
+}
+
+func (r *logReporter) Close() error {
+	if r.matrix == nil {
+		return nil
+	}
+	return r.matrix.Print(r.w)
+}
+
+type jsonReporter struct {
+	w       io.Writer
+	m       sync.Mutex
+	records []Record
+}
+
+func (r *jsonReporter) Report(record Record) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.records = append(r.records, record)
+}
+
+func (r *jsonReporter) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	e := json.NewEncoder(r.w)
+	e.SetIndent("", "  ")
+	return e.Encode(r.records)
+}
+
+type ndjsonReporter struct {
+	m   sync.Mutex
+	e   *json.Encoder
+	err error
+}
+
+func (r *ndjsonReporter) Report(record Record) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.err == nil {
+		r.err = r.e.Encode(record)
+	}
+}
+
+func (r *ndjsonReporter) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.err
+}
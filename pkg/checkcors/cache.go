@@ -0,0 +1,110 @@
+package checkcors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxAgeFirefox  = 86400
+	maxAgeChromium = 7200
+)
+
+// AltOrigin is the origin sent with the second request made by cache
+// checks.
+const AltOrigin = "https://checkcors.invalid"
+
+// EvaluateCache checks that a response can be safely cached. h is the
+// response to the request described by req, and alt is the response to
+// an otherwise identical request sent from AltOrigin. alt is ignored
+// for preflight requests.
+func EvaluateCache(req Request, h, alt http.Header) []Verdict {
+	var verdicts []Verdict
+	if v, ok := evalCacheMaxAge(h); ok {
+		verdicts = append(verdicts, v)
+	}
+	if req.Preflight {
+		return verdicts
+	}
+
+	verdicts = append(verdicts, evalCacheVary(h, alt))
+	if v, ok := evalCacheShared(h); ok {
+		verdicts = append(verdicts, v)
+	}
+	return verdicts
+}
+
+func evalCacheMaxAge(h http.Header) (Verdict, bool) {
+	maxage := h.Get("Access-Control-Max-Age")
+	if maxage == "" {
+		return Verdict{}, false
+	}
+
+	v := check(RuleCacheMaxAge, h, "Access-Control-Max-Age", "1 to "+strconv.FormatInt(maxAgeChromium, 10))
+	n, err := strconv.ParseInt(maxage, 10, 64)
+	switch {
+	case err != nil || n < 0:
+		return v.fail("Access-Control-Max-Age %q is invalid and will be ignored", maxage), true
+	case n == 0:
+		return v.fail("Access-Control-Max-Age of 0 disables preflight caching"), true
+	case n > maxAgeFirefox:
+		return v.fail("Access-Control-Max-Age %v exceeds every browser's limit and will be clamped", n), true
+	case n > maxAgeChromium:
+		return v.fail("Access-Control-Max-Age %v exceeds Chromium's limit of %v and will be clamped", n, maxAgeChromium), true
+	default:
+		return v.pass("preflight cacheable for %v seconds", n), true
+	}
+}
+
+func evalCacheVary(h, alt http.Header) Verdict {
+	v := check(RuleCacheVary, h, "Vary", "Origin")
+
+	allow := strings.Join(h.Values("Access-Control-Allow-Origin"), ", ")
+	altAllow := strings.Join(alt.Values("Access-Control-Allow-Origin"), ", ")
+	if allow == altAllow {
+		return v.pass("Access-Control-Allow-Origin does not depend on origin")
+	}
+
+	if !varies(h, "Origin") || !varies(alt, "Origin") {
+		return v.fail("Access-Control-Allow-Origin changes with origin (%q vs. %q from %v) but the response is missing Vary: Origin", allow, altAllow, AltOrigin)
+	}
+	return v.pass("Access-Control-Allow-Origin changes with origin and the response varies by origin")
+}
+
+func evalCacheShared(h http.Header) (Verdict, bool) {
+	allow := h.Get("Access-Control-Allow-Origin")
+	if allow == "" || allow == "*" || !sharedCacheable(h) {
+		return Verdict{}, false
+	}
+
+	v := check(RuleCacheShared, h, "Cache-Control", "private, no-store, or Vary: Origin")
+	if !varies(h, "Origin") {
+		return v.fail("response is cacheable by shared caches with origin-specific Access-Control-Allow-Origin %q and no Vary: Origin", allow), true
+	}
+	return v.pass("cacheable origin-specific response varies by origin"), true
+}
+
+func varies(h http.Header, name string) bool {
+	vary := parseList(h.Values("Vary"))
+	return containsFold(vary, name) || slices.Contains(vary, "*")
+}
+
+func sharedCacheable(h http.Header) bool {
+	cc := parseList(h.Values("Cache-Control"))
+	cacheable := h.Get("Expires") != ""
+	for _, directive := range cc {
+		name, val, _ := strings.Cut(strings.ToLower(directive), "=")
+		switch name {
+		case "no-store", "private", "no-cache":
+			return false
+		case "public":
+			cacheable = true
+		case "max-age", "s-maxage":
+			n, err := strconv.ParseInt(strings.Trim(val, `"`), 10, 64)
+			cacheable = cacheable || (err == nil && n > 0)
+		}
+	}
+	return cacheable
+}
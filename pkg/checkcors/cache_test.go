@@ -0,0 +1,66 @@
+package checkcors
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSharedCacheable(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.Header
+		want bool
+	}{
+		{"None", header(), false},
+		{"Public", header("Cache-Control", "public"), true},
+		{"MaxAge", header("Cache-Control", "max-age=60"), true},
+		{"MaxAgeZero", header("Cache-Control", "max-age=0"), false},
+		{"SMaxAgeQuoted", header("Cache-Control", `s-maxage="60"`), true},
+		{"Expires", header("Expires", "Wed, 21 Oct 2015 07:28:00 GMT"), true},
+		{"Private", header("Cache-Control", "private, max-age=60"), false},
+		{"NoStore", header("Cache-Control", "public", "Cache-Control", "no-store"), false},
+		{"NoCache", header("Cache-Control", "No-Cache, max-age=60"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := sharedCacheable(test.h)
+			if got != test.want {
+				t.Errorf("sharedCacheable(%v) = %v, want %v", test.h, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEvalCacheMaxAge(t *testing.T) {
+	tests := []struct {
+		maxage  string
+		checked bool
+		ok      bool
+	}{
+		{"", false, false},
+		{"600", true, true},
+		{"7200", true, true},
+		{"7201", true, false},
+		{"86401", true, false},
+		{"0", true, false},
+		{"-1", true, false},
+		{"ten", true, false},
+	}
+
+	for _, test := range tests {
+		h := header()
+		if test.maxage != "" {
+			h.Set("Access-Control-Max-Age", test.maxage)
+		}
+
+		v, checked := evalCacheMaxAge(h)
+		if checked != test.checked {
+			t.Errorf("%q: checked = %v, want %v", test.maxage, checked, test.checked)
+			continue
+		}
+		if checked && v.OK != test.ok {
+			t.Errorf("%q: ok = %v, want %v (%v)", test.maxage, v.OK, test.ok, v.Detail)
+		}
+	}
+}
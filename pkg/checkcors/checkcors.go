@@ -0,0 +1,272 @@
+// Package checkcors checks HTTP endpoints for correct CORS behavior,
+// evaluating responses the way a browser following the Fetch spec
+// would.
+package checkcors
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Checker performs CORS checks against URLs. The zero value is not
+// usable; Client must be set.
+type Checker struct {
+	Client    *http.Client
+	ReqHeader http.Header
+	Expect    map[string]Expectation
+
+	// Credentials causes requests to be evaluated as credentialed
+	// requests. CredentialHeader, typically Cookie and Authorization,
+	// is sent with non-preflight requests only, as a browser would.
+	Credentials      bool
+	CredentialHeader http.Header
+
+	// Method is the method of the request being checked. If Preflight
+	// is set, an OPTIONS request asking permission for Method is sent
+	// instead of the request itself.
+	Preflight      bool
+	Method         string
+	RequestHeaders []string
+	ContentType    string
+
+	Redirects RedirectMode
+
+	// CacheChecks enables checks that the response can be safely
+	// cached. This sends a second request from AltOrigin.
+	CacheChecks bool
+
+	Retries      int
+	RetryBackoff time.Duration
+}
+
+// Origin is an origin to send with a check along with whether or not
+// the server is expected to allow it. The zero Origin sends no Origin
+// header at all.
+type Origin struct {
+	Origin string
+	Allow  bool
+}
+
+// Result is the outcome of checking a single URL from a single origin.
+type Result struct {
+	URL       string
+	Origin    Origin
+	Method    string
+	Preflight bool
+	Status    int
+	Header    http.Header
+	Verdicts  []Verdict
+	Redirects []Hop
+	Attempts  int
+	Duration  time.Duration
+}
+
+// Allowed reports whether a browser would allow the request, i.e.
+// whether every access verdict passed.
+func (r Result) Allowed() bool {
+	return !slices.ContainsFunc(r.Verdicts, func(v Verdict) bool { return !v.OK && v.Rule.Access() })
+}
+
+// Passed reports whether the result matched the expectation of its
+// origin. Origins that are expected to be allowed must also pass every
+// other verdict.
+func (r Result) Passed() bool {
+	if !r.Origin.Allow {
+		return !r.Allowed()
+	}
+	return !slices.ContainsFunc(r.Verdicts, func(v Verdict) bool { return !v.OK })
+}
+
+// Failures returns the verdicts that did not pass.
+func (r Result) Failures() []Verdict {
+	var failures []Verdict
+	for _, v := range r.Verdicts {
+		if !v.OK {
+			failures = append(failures, v)
+		}
+	}
+	return failures
+}
+
+// Check checks url from origin, retrying transient failures according
+// to c.Retries. An error is only returned if the check could not be
+// performed; a response that fails the CORS rules is reported via the
+// returned Result.
+func (c Checker) Check(ctx context.Context, url string, origin Origin) (result Result, err error) {
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		result, retry, err = c.check(ctx, url, origin)
+		result.Attempts = attempt
+		if !retry || attempt > c.Retries || ctx.Err() != nil {
+			return result, err
+		}
+
+		if err := sleep(ctx, backoff(c.RetryBackoff, attempt)); err != nil {
+			return result, err
+		}
+	}
+}
+
+func (c Checker) check(ctx context.Context, url string, origin Origin) (Result, bool, error) {
+	result := Result{URL: url, Origin: origin, Method: cmp.Or(c.Method, "GET"), Preflight: c.Preflight}
+
+	method := result.Method
+	if c.Preflight {
+		method = "OPTIONS"
+	}
+
+	requested := c.RequestHeaders
+	if c.Credentials && c.Preflight {
+		for name := range c.CredentialHeader {
+			if !strings.EqualFold(name, "Cookie") {
+				requested = append(slices.Clip(requested), name)
+			}
+		}
+	}
+
+	client := c.redirectClient()
+	reqorigin := origin.Origin
+	for {
+		status, header, retry, err := c.send(ctx, client, method, url, reqorigin, requested)
+		if err != nil {
+			return result, retry, err
+		}
+
+		location, ok := redirectLocation(url, status, header)
+		if !ok || c.Redirects != RedirectValidate || c.Preflight {
+			evalreq := Request{
+				Origin:      reqorigin,
+				Method:      c.Method,
+				Headers:     requested,
+				ContentType: c.ContentType,
+				Credentials: c.Credentials,
+				Preflight:   c.Preflight,
+			}
+
+			result.Status = status
+			result.Header = header
+			result.Verdicts = append(result.Verdicts, Evaluate(evalreq, status, header)...)
+			result.Verdicts = append(result.Verdicts, EvaluateExpect(header, c.Expect)...)
+
+			if c.CacheChecks {
+				var alt http.Header
+				if !c.Preflight {
+					_, alt, retry, err = c.send(ctx, client, method, url, AltOrigin, requested)
+					if err != nil {
+						return result, retry, fmt.Errorf("cache check: %w", err)
+					}
+				}
+				result.Verdicts = append(result.Verdicts, EvaluateCache(evalreq, header, alt)...)
+			}
+
+			return result, isTransientStatus(status), nil
+		}
+
+		hop := Hop{URL: url, Status: status, Location: location}
+		hop.Verdicts = Evaluate(
+			Request{
+				Origin:      reqorigin,
+				Method:      method,
+				Credentials: c.Credentials,
+			},
+			status,
+			header,
+		)
+		result.Redirects = append(result.Redirects, hop)
+		result.Verdicts = append(result.Verdicts, hop.verdict(len(result.Redirects)))
+		if len(result.Redirects) >= maxRedirects {
+			return result, false, fmt.Errorf("stopped after %v redirects", maxRedirects)
+		}
+
+		if taintsOrigin(reqorigin, url, location) {
+			reqorigin = "null"
+		}
+		method = redirectMethod(method, status)
+		url = location
+	}
+}
+
+func (c Checker) send(ctx context.Context, client *http.Client, method, url, origin string, requested []string) (int, http.Header, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("create request: %w", err)
+	}
+	for name, vals := range c.ReqHeader {
+		req.Header[name] = vals
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if c.Credentials && !c.Preflight {
+		for name, vals := range c.CredentialHeader {
+			req.Header[name] = vals
+		}
+	}
+	if c.ContentType != "" && !c.Preflight {
+		req.Header.Set("Content-Type", c.ContentType)
+	}
+	if c.Preflight {
+		req.Header.Set("Access-Control-Request-Method", c.Method)
+		if reqheaders := preflightHeaders(requested, c.ContentType); len(reqheaders) != 0 {
+			req.Header.Set("Access-Control-Request-Headers", strings.Join(reqheaders, ","))
+		}
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, true, fmt.Errorf("perform request: %w", err)
+	}
+	defer rsp.Body.Close()
+
+	_, err = io.Copy(io.Discard, rsp.Body)
+	if err != nil {
+		return 0, nil, true, fmt.Errorf("read body: %w", err)
+	}
+
+	return rsp.StatusCode, rsp.Header, false, nil
+}
+
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func backoff(base time.Duration, attempt int) time.Duration {
+	const limit = 30 * time.Second
+
+	if base <= 0 {
+		return 0
+	}
+
+	d := base << (attempt - 1)
+	if d < base || d > limit {
+		d = limit
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package checkcors
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		base    time.Duration
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{100 * time.Millisecond, 1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{100 * time.Millisecond, 2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{100 * time.Millisecond, 4, 400 * time.Millisecond, 800 * time.Millisecond},
+		{10 * time.Second, 3, 15 * time.Second, 30 * time.Second},
+		{time.Second, 70, 15 * time.Second, 30 * time.Second},
+		{time.Second, 34, 15 * time.Second, 30 * time.Second},
+		{0, 1, 0, 0},
+		{0, 5, 0, 0},
+		{-time.Second, 2, 0, 0},
+	}
+
+	for _, test := range tests {
+		for range 100 {
+			d := backoff(test.base, test.attempt)
+			if d < test.min || d > test.max {
+				t.Errorf("backoff(%v, %v) = %v, want between %v and %v", test.base, test.attempt, d, test.min, test.max)
+				break
+			}
+		}
+	}
+}
+
+func TestIsTransientStatus(t *testing.T) {
+	tests := map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNotFound:            false,
+		http.StatusInternalServerError: false,
+		http.StatusTooManyRequests:     true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+	}
+
+	for status, want := range tests {
+		if got := isTransientStatus(status); got != want {
+			t.Errorf("isTransientStatus(%v) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package checkcors
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Rule identifies the CORS rule that a Verdict is about.
+type Rule string
+
+const (
+	RuleStatus      Rule = "status"
+	RuleOrigin      Rule = "allow-origin"
+	RuleCredentials Rule = "allow-credentials"
+	RuleAccepted    Rule = "credentials-accepted"
+	RuleMethods     Rule = "allow-methods"
+	RuleHeaders     Rule = "allow-headers"
+	RuleMaxAge      Rule = "max-age"
+	RuleVary        Rule = "vary"
+	RuleRedirect    Rule = "redirect"
+	RuleCacheVary   Rule = "cache-vary"
+	RuleCacheMaxAge Rule = "cache-max-age"
+	RuleCacheShared Rule = "cache-shared"
+	RuleExpect      Rule = "expect"
+)
+
+// Access reports whether a failure of the rule would cause a browser
+// to block the request.
+func (r Rule) Access() bool {
+	switch r {
+	case RuleStatus, RuleOrigin, RuleCredentials, RuleMethods, RuleHeaders, RuleRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// Verdict is the outcome of evaluating a single rule against a
+// response.
+type Verdict struct {
+	Rule     Rule   `json:"rule"`
+	OK       bool   `json:"ok"`
+	Header   string `json:"header,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual"`
+	Detail   string `json:"detail"`
+}
+
+func check(rule Rule, h http.Header, header, expected string) Verdict {
+	return Verdict{
+		Rule:     rule,
+		Header:   header,
+		Expected: expected,
+		Actual:   strings.Join(h.Values(header), ", "),
+	}
+}
+
+func (v Verdict) pass(format string, args ...any) Verdict {
+	v.OK = true
+	v.Detail = fmt.Sprintf(format, args...)
+	return v
+}
+
+func (v Verdict) fail(format string, args ...any) Verdict {
+	v.OK = false
+	v.Detail = fmt.Sprintf(format, args...)
+	return v
+}
+
+// Request describes the request that a response is being evaluated
+// for.
+type Request struct {
+	Origin      string
+	Method      string
+	Headers     []string
+	ContentType string
+	Credentials bool
+	Preflight   bool
+}
+
+// Evaluate evaluates a response with the given status and headers
+// against the CORS rules that apply to req.
+func Evaluate(req Request, status int, h http.Header) []Verdict {
+	var verdicts []Verdict
+	if req.Preflight {
+		verdicts = append(verdicts, evalStatus(status))
+	}
+	verdicts = append(verdicts, evalOrigin(req, h))
+	if req.Credentials {
+		verdicts = append(verdicts, evalCredentials(h))
+		if !req.Preflight {
+			verdicts = append(verdicts, evalAccepted(status))
+		}
+	}
+	if req.Preflight {
+		verdicts = append(verdicts, evalMethods(req, h))
+		verdicts = append(verdicts, evalHeaders(req, h)...)
+		if v, ok := evalMaxAge(h); ok {
+			verdicts = append(verdicts, v)
+		}
+	}
+	if v, ok := evalVary(h); ok {
+		verdicts = append(verdicts, v)
+	}
+	return verdicts
+}
+
+func evalStatus(status int) Verdict {
+	v := Verdict{Rule: RuleStatus, Expected: "2xx", Actual: strconv.FormatInt(int64(status), 10)}
+	if status >= 300 && status <= 399 {
+		return v.fail("preflight status %v is a redirect, which browsers do not follow", status)
+	}
+	if status < 200 || status > 299 {
+		return v.fail("preflight status %v is not an ok status", status)
+	}
+	return v.pass("preflight status %v", status)
+}
+
+func evalOrigin(req Request, h http.Header) Verdict {
+	expected := "*"
+	switch {
+	case req.Origin != "" && req.Credentials:
+		expected = req.Origin
+	case req.Origin != "":
+		expected = req.Origin + " or *"
+	}
+	v := check(RuleOrigin, h, "Access-Control-Allow-Origin", expected)
+
+	vals := h.Values("Access-Control-Allow-Origin")
+	switch len(vals) {
+	case 0:
+		return v.fail("Access-Control-Allow-Origin is missing")
+	case 1:
+	default:
+		return v.fail("Access-Control-Allow-Origin has multiple values %q", vals)
+	}
+
+	allow := vals[0]
+	switch {
+	case allow == "*" && req.Credentials:
+		return v.fail("Access-Control-Allow-Origin: * is not allowed for credentialed requests; the request origin must be echoed")
+	case allow == "*":
+		return v.pass("wildcard origin allowed")
+	case req.Origin == "":
+		return v.fail("origin %q only allows a specific origin", allow)
+	case allow == req.Origin:
+		return v.pass("origin %q allowed", allow)
+	default:
+		return v.fail("origin %q does not match allowed origin %q", req.Origin, allow)
+	}
+}
+
+func evalCredentials(h http.Header) Verdict {
+	v := check(RuleCredentials, h, "Access-Control-Allow-Credentials", "true")
+	allow := h.Get("Access-Control-Allow-Credentials")
+	if allow != "true" {
+		return v.fail("Access-Control-Allow-Credentials is %q, not \"true\"", allow)
+	}
+	return v.pass("credentials allowed")
+}
+
+func evalAccepted(status int) Verdict {
+	v := Verdict{Rule: RuleAccepted, Expected: "not 401 or 403", Actual: strconv.FormatInt(int64(status), 10)}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return v.fail("credentials were rejected with status %v", status)
+	}
+	return v.pass("credentials accepted with status %v", status)
+}
+
+func evalMethods(req Request, h http.Header) Verdict {
+	v := check(RuleMethods, h, "Access-Control-Allow-Methods", req.Method)
+	methods := parseList(h.Values("Access-Control-Allow-Methods"))
+	switch {
+	case slices.Contains(methods, req.Method):
+		return v.pass("method %v allowed", req.Method)
+	case slices.Contains(methods, "*") && !req.Credentials:
+		return v.pass("method %v allowed by wildcard", req.Method)
+	case isSafelistedMethod(req.Method):
+		return v.pass("method %v is CORS-safelisted", req.Method)
+	default:
+		return v.fail("method %v not in allowed methods %q", req.Method, methods)
+	}
+}
+
+func evalHeaders(req Request, h http.Header) []Verdict {
+	allowed := parseList(h.Values("Access-Control-Allow-Headers"))
+	wildcard := slices.Contains(allowed, "*") && !req.Credentials
+
+	var verdicts []Verdict
+	for _, name := range preflightHeaders(req.Headers, req.ContentType) {
+		v := check(RuleHeaders, h, "Access-Control-Allow-Headers", name)
+		switch {
+		case containsFold(allowed, name):
+			verdicts = append(verdicts, v.pass("header %v allowed", name))
+		case wildcard && name != "authorization":
+			verdicts = append(verdicts, v.pass("header %v allowed by wildcard", name))
+		default:
+			verdicts = append(verdicts, v.fail("header %v not in allowed headers %q", name, allowed))
+		}
+	}
+	return verdicts
+}
+
+func evalMaxAge(h http.Header) (Verdict, bool) {
+	maxage := h.Get("Access-Control-Max-Age")
+	if maxage == "" {
+		return Verdict{}, false
+	}
+
+	v := check(RuleMaxAge, h, "Access-Control-Max-Age", "non-negative integer")
+	_, err := strconv.ParseUint(maxage, 10, 64)
+	if err != nil {
+		return v.fail("Access-Control-Max-Age %q is not a non-negative integer", maxage), true
+	}
+	return v.pass("preflight cacheable for %v seconds", maxage), true
+}
+
+func evalVary(h http.Header) (Verdict, bool) {
+	allow := h.Get("Access-Control-Allow-Origin")
+	if allow == "" || allow == "*" {
+		return Verdict{}, false
+	}
+
+	v := check(RuleVary, h, "Vary", "Origin")
+	vary := parseList(h.Values("Vary"))
+	if !containsFold(vary, "Origin") && !slices.Contains(vary, "*") {
+		return v.fail("origin-specific response is missing Vary: Origin"), true
+	}
+	return v.pass("response varies by origin"), true
+}
+
+// EvaluateExpect checks h against a set of expected headers.
+func EvaluateExpect(h http.Header, expect map[string]Expectation) []Verdict {
+	var verdicts []Verdict
+	for name, e := range expect {
+		v := check(RuleExpect, h, name, e.String())
+		if !e.Match(h, name) {
+			verdicts = append(verdicts, v.fail("header %v: expected %v, got %q", name, e, h.Values(name)))
+			continue
+		}
+		verdicts = append(verdicts, v.pass("header %v matches %v", name, e))
+	}
+	slices.SortFunc(verdicts, func(v1, v2 Verdict) int { return strings.Compare(v1.Header, v2.Header) })
+	return verdicts
+}
+
+// CORSHeaders returns the subset of h that is relevant to CORS, i.e.
+// the Access-Control-* headers and Vary.
+func CORSHeaders(h http.Header) http.Header {
+	cors := make(http.Header)
+	for name, vals := range h {
+		if strings.HasPrefix(name, "Access-Control-") || name == "Vary" {
+			cors[name] = vals
+		}
+	}
+	return cors
+}
+
+// NeedsPreflight reports whether a browser would send a preflight
+// request before a request with the given method, headers, and
+// Content-Type. A Content-Type header listed in headers without a
+// known contentType is assumed not to be safelisted.
+func NeedsPreflight(method string, headers []string, contentType string) bool {
+	return !isSafelistedMethod(method) || len(preflightHeaders(headers, contentType)) != 0
+}
+
+func preflightHeaders(headers []string, contentType string) []string {
+	var names []string
+	if contentType != "" && !isSafelistedContentType(contentType) {
+		names = append(names, "content-type")
+	}
+	for _, name := range headers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || slices.Contains(names, name) {
+			continue
+		}
+		if name == "content-type" && contentType != "" || isSafelistedHeader(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func isSafelistedMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "POST":
+		return true
+	default:
+		return false
+	}
+}
+
+func isSafelistedHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "accept", "accept-language", "content-language", "range":
+		return true
+	default:
+		return false
+	}
+}
+
+func isSafelistedContentType(contentType string) bool {
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediatype {
+	case "application/x-www-form-urlencoded", "multipart/form-data", "text/plain":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseList(vals []string) []string {
+	var list []string
+	for _, val := range vals {
+		for _, item := range strings.Split(val, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(item, s)
+	})
+}
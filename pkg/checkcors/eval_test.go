@@ -0,0 +1,218 @@
+package checkcors
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func header(kv ...string) http.Header {
+	h := make(http.Header)
+	for i := 0; i < len(kv); i += 2 {
+		h.Add(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func TestEvalOrigin(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		h    http.Header
+		ok   bool
+	}{
+		{"Missing", Request{Origin: "https://a.example"}, header(), false},
+		{"Wildcard", Request{Origin: "https://a.example"}, header("Access-Control-Allow-Origin", "*"), true},
+		{"WildcardNoOrigin", Request{}, header("Access-Control-Allow-Origin", "*"), true},
+		{"WildcardCredentials", Request{Origin: "https://a.example", Credentials: true}, header("Access-Control-Allow-Origin", "*"), false},
+		{"Echo", Request{Origin: "https://a.example"}, header("Access-Control-Allow-Origin", "https://a.example"), true},
+		{"EchoCredentials", Request{Origin: "https://a.example", Credentials: true}, header("Access-Control-Allow-Origin", "https://a.example"), true},
+		{"Mismatch", Request{Origin: "https://a.example"}, header("Access-Control-Allow-Origin", "https://b.example"), false},
+		{"CaseSensitive", Request{Origin: "https://a.example"}, header("Access-Control-Allow-Origin", "https://A.example"), false},
+		{"SpecificNoOrigin", Request{}, header("Access-Control-Allow-Origin", "https://a.example"), false},
+		{"Null", Request{Origin: "null"}, header("Access-Control-Allow-Origin", "null"), true},
+		{"Multiple", Request{Origin: "https://a.example"}, header("Access-Control-Allow-Origin", "https://a.example", "Access-Control-Allow-Origin", "*"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := evalOrigin(test.req, test.h)
+			if v.Rule != RuleOrigin {
+				t.Errorf("rule = %v, want %v", v.Rule, RuleOrigin)
+			}
+			if v.OK != test.ok {
+				t.Errorf("ok = %v, want %v (%v)", v.OK, test.ok, v.Detail)
+			}
+		})
+	}
+}
+
+func TestEvalMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		h    http.Header
+		ok   bool
+	}{
+		{"Listed", Request{Method: "PUT"}, header("Access-Control-Allow-Methods", "GET, PUT"), true},
+		{"ListedSeparately", Request{Method: "PUT"}, header("Access-Control-Allow-Methods", "GET", "Access-Control-Allow-Methods", "PUT"), true},
+		{"Missing", Request{Method: "DELETE"}, header("Access-Control-Allow-Methods", "GET, PUT"), false},
+		{"NoHeader", Request{Method: "DELETE"}, header(), false},
+		{"CaseSensitive", Request{Method: "PUT"}, header("Access-Control-Allow-Methods", "put"), false},
+		{"Safelisted", Request{Method: "POST"}, header(), true},
+		{"Wildcard", Request{Method: "DELETE"}, header("Access-Control-Allow-Methods", "*"), true},
+		{"WildcardCredentials", Request{Method: "DELETE", Credentials: true}, header("Access-Control-Allow-Methods", "*"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := evalMethods(test.req, test.h)
+			if v.OK != test.ok {
+				t.Errorf("ok = %v, want %v (%v)", v.OK, test.ok, v.Detail)
+			}
+		})
+	}
+}
+
+func TestEvalHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		h    http.Header
+		want map[string]bool
+	}{
+		{
+			name: "Allowed",
+			req:  Request{Headers: []string{"X-Foo", "x-bar"}},
+			h:    header("Access-Control-Allow-Headers", "x-foo, X-Bar"),
+			want: map[string]bool{"x-foo": true, "x-bar": true},
+		},
+		{
+			name: "NotAllowed",
+			req:  Request{Headers: []string{"X-Foo", "X-Bar"}},
+			h:    header("Access-Control-Allow-Headers", "X-Foo"),
+			want: map[string]bool{"x-foo": true, "x-bar": false},
+		},
+		{
+			name: "Safelisted",
+			req:  Request{Headers: []string{"Accept", "Content-Language", ""}},
+			h:    header(),
+			want: map[string]bool{},
+		},
+		{
+			name: "Wildcard",
+			req:  Request{Headers: []string{"X-Foo", "Authorization"}},
+			h:    header("Access-Control-Allow-Headers", "*"),
+			want: map[string]bool{"x-foo": true, "authorization": false},
+		},
+		{
+			name: "WildcardCredentials",
+			req:  Request{Headers: []string{"X-Foo"}, Credentials: true},
+			h:    header("Access-Control-Allow-Headers", "*"),
+			want: map[string]bool{"x-foo": false},
+		},
+		{
+			name: "SafelistedContentType",
+			req:  Request{ContentType: "text/plain; charset=utf-8"},
+			h:    header(),
+			want: map[string]bool{},
+		},
+		{
+			name: "ContentType",
+			req:  Request{ContentType: "application/json"},
+			h:    header(),
+			want: map[string]bool{"content-type": false},
+		},
+		{
+			name: "ContentTypeAllowed",
+			req:  Request{Headers: []string{"Content-Type"}, ContentType: "application/json"},
+			h:    header("Access-Control-Allow-Headers", "Content-Type"),
+			want: map[string]bool{"content-type": true},
+		},
+		{
+			name: "ContentTypeUnknownValue",
+			req:  Request{Headers: []string{"Content-Type"}},
+			h:    header(),
+			want: map[string]bool{"content-type": false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verdicts := evalHeaders(test.req, test.h)
+			if len(verdicts) != len(test.want) {
+				t.Fatalf("got %v verdicts, want %v: %+v", len(verdicts), len(test.want), verdicts)
+			}
+			for _, v := range verdicts {
+				name := strings.Fields(v.Detail)[1]
+				ok, found := test.want[name]
+				if !found {
+					t.Errorf("unexpected verdict for %v", name)
+					continue
+				}
+				if v.OK != ok {
+					t.Errorf("%v: ok = %v, want %v (%v)", name, v.OK, ok, v.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestEvalVary(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       http.Header
+		checked bool
+		ok      bool
+	}{
+		{"NoAllowOrigin", header(), false, false},
+		{"Wildcard", header("Access-Control-Allow-Origin", "*"), false, false},
+		{"Missing", header("Access-Control-Allow-Origin", "https://a.example"), true, false},
+		{"Other", header("Access-Control-Allow-Origin", "https://a.example", "Vary", "Accept-Encoding"), true, false},
+		{"Origin", header("Access-Control-Allow-Origin", "https://a.example", "Vary", "Accept-Encoding, origin"), true, true},
+		{"OriginSeparately", header("Access-Control-Allow-Origin", "https://a.example", "Vary", "Accept-Encoding", "Vary", "Origin"), true, true},
+		{"Star", header("Access-Control-Allow-Origin", "https://a.example", "Vary", "*"), true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, checked := evalVary(test.h)
+			if checked != test.checked {
+				t.Fatalf("checked = %v, want %v", checked, test.checked)
+			}
+			if checked && v.OK != test.ok {
+				t.Errorf("ok = %v, want %v (%v)", v.OK, test.ok, v.Detail)
+			}
+		})
+	}
+}
+
+func TestNeedsPreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		headers     []string
+		contentType string
+		want        bool
+	}{
+		{"Get", "GET", nil, "", false},
+		{"Post", "POST", nil, "", false},
+		{"Delete", "DELETE", nil, "", true},
+		{"SafelistedHeader", "GET", []string{"Accept", "Range"}, "", false},
+		{"CustomHeader", "GET", []string{"X-Foo"}, "", true},
+		{"EmptyHeader", "GET", []string{""}, "", false},
+		{"FormPost", "POST", nil, "application/x-www-form-urlencoded", false},
+		{"ContentTypeWithoutValue", "GET", []string{"Content-Type"}, "", true},
+		{"JSONPost", "POST", nil, "application/json", true},
+		{"InvalidContentType", "POST", nil, "text/plain;;", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NeedsPreflight(test.method, test.headers, test.contentType)
+			if got != test.want {
+				t.Errorf("NeedsPreflight(%q, %q, %q) = %v, want %v", test.method, test.headers, test.contentType, got, test.want)
+			}
+		})
+	}
+}
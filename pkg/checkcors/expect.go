@@ -0,0 +1,78 @@
+package checkcors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Expectation is a requirement on a single response header. Exactly
+// one field should be set. In JSON, a plain string is shorthand for
+// {"equal": "..."}.
+type Expectation struct {
+	Equal   string   `json:"equal,omitempty"`
+	Any     bool     `json:"any,omitempty"`
+	Absent  bool     `json:"absent,omitempty"`
+	Include []string `json:"include,omitempty"`
+}
+
+func (e *Expectation) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*e = Expectation{}
+		return json.Unmarshal(data, &e.Equal)
+	}
+
+	type expectation Expectation
+	err := json.Unmarshal(data, (*expectation)(e))
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, set := range []bool{e.Equal != "", e.Any, e.Absent, len(e.Include) != 0} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return errors.New("expectation must specify exactly one of equal, any, absent, or include")
+	}
+	return nil
+}
+
+func (e Expectation) String() string {
+	switch {
+	case e.Any:
+		return "<any>"
+	case e.Absent:
+		return "<absent>"
+	case len(e.Include) != 0:
+		return fmt.Sprintf("<include %v>", strings.Join(e.Include, ", "))
+	default:
+		return e.Equal
+	}
+}
+
+// Match reports whether the named header in h meets the expectation.
+func (e Expectation) Match(h http.Header, name string) bool {
+	vals := h.Values(name)
+	switch {
+	case e.Any:
+		return len(vals) != 0
+	case e.Absent:
+		return len(vals) == 0
+	case len(e.Include) != 0:
+		list := parseList(vals)
+		for _, item := range e.Include {
+			if !containsFold(list, item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return h.Get(name) == e.Equal
+	}
+}
@@ -0,0 +1,79 @@
+package checkcors
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExpectationUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Expectation
+		err  bool
+	}{
+		{"String", `"https://a.example"`, Expectation{Equal: "https://a.example"}, false},
+		{"StringSpace", ` "GET"`, Expectation{Equal: "GET"}, false},
+		{"Equal", `{"equal": "true"}`, Expectation{Equal: "true"}, false},
+		{"Any", `{"any": true}`, Expectation{Any: true}, false},
+		{"Absent", `{"absent": true}`, Expectation{Absent: true}, false},
+		{"Include", `{"include": ["Origin"]}`, Expectation{Include: []string{"Origin"}}, false},
+		{"None", `{}`, Expectation{}, true},
+		{"Several", `{"any": true, "absent": true}`, Expectation{}, true},
+		{"Invalid", `[]`, Expectation{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var e Expectation
+			err := json.Unmarshal([]byte(test.data), &e)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", e)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(e, test.want) {
+				t.Errorf("got %+v, want %+v", e, test.want)
+			}
+		})
+	}
+}
+
+func TestExpectationMatch(t *testing.T) {
+	h := http.Header{
+		"Access-Control-Allow-Origin": {"https://a.example"},
+		"Vary":                        {"Accept-Encoding", "origin, Cookie"},
+	}
+
+	tests := []struct {
+		name   string
+		e      Expectation
+		header string
+		want   bool
+	}{
+		{"Equal", Expectation{Equal: "https://a.example"}, "Access-Control-Allow-Origin", true},
+		{"NotEqual", Expectation{Equal: "*"}, "Access-Control-Allow-Origin", false},
+		{"EqualMissing", Expectation{Equal: "true"}, "Access-Control-Allow-Credentials", false},
+		{"Any", Expectation{Any: true}, "access-control-allow-origin", true},
+		{"AnyMissing", Expectation{Any: true}, "Access-Control-Max-Age", false},
+		{"Absent", Expectation{Absent: true}, "Access-Control-Max-Age", true},
+		{"NotAbsent", Expectation{Absent: true}, "Vary", false},
+		{"Include", Expectation{Include: []string{"Origin", "accept-encoding"}}, "Vary", true},
+		{"NotIncluded", Expectation{Include: []string{"Origin", "Accept"}}, "Vary", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.e.Match(h, test.header)
+			if got != test.want {
+				t.Errorf("%v.Match(%v) = %v, want %v", test.e, test.header, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package checkcors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const maxRedirects = 20
+
+// RedirectMode controls how a Checker handles redirects.
+type RedirectMode string
+
+const (
+	// RedirectFollow follows redirects using the client's policy and
+	// only evaluates the final response.
+	RedirectFollow RedirectMode = "follow"
+
+	// RedirectNone does not follow redirects and evaluates the
+	// redirect response itself.
+	RedirectNone RedirectMode = "none"
+
+	// RedirectValidate follows redirects itself, evaluating the access
+	// rules against every response in the chain as a browser would.
+	RedirectValidate RedirectMode = "validate"
+)
+
+func (m *RedirectMode) Set(val string) error {
+	switch mode := RedirectMode(val); mode {
+	case RedirectFollow, RedirectNone, RedirectValidate:
+		*m = mode
+		return nil
+	default:
+		return fmt.Errorf("unknown redirect mode %q", val)
+	}
+}
+
+func (m RedirectMode) String() string {
+	return string(m)
+}
+
+// Hop is a single redirect response in a redirect chain.
+type Hop struct {
+	URL      string    `json:"url"`
+	Status   int       `json:"status"`
+	Location string    `json:"location"`
+	Verdicts []Verdict `json:"checks"`
+}
+
+func (h Hop) verdict(n int) Verdict {
+	v := Verdict{Rule: RuleRedirect, Expected: "CORS headers on redirect", Actual: fmt.Sprintf("%v %v", h.Status, h.URL)}
+
+	var failures []string
+	for _, hv := range h.Verdicts {
+		if !hv.OK && hv.Rule.Access() {
+			failures = append(failures, hv.Detail)
+		}
+	}
+	if len(failures) != 0 {
+		return v.fail("redirect %v from %v to %v failed: %v", n, h.URL, h.Location, strings.Join(failures, "; "))
+	}
+	return v.pass("redirect %v from %v to %v passed", n, h.URL, h.Location)
+}
+
+// redirectClient returns the client to send requests with. Browsers
+// never follow a redirected preflight, so preflights are always sent
+// without following redirects.
+func (c Checker) redirectClient() *http.Client {
+	if !c.Preflight && (c.Redirects == "" || c.Redirects == RedirectFollow) {
+		return c.Client
+	}
+
+	client := *c.Client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &client
+}
+
+func redirectLocation(base string, status int, h http.Header) (string, bool) {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return "", false
+	}
+
+	loc := h.Get("Location")
+	if loc == "" {
+		return "", false
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", false
+	}
+	next, err := u.Parse(loc)
+	if err != nil {
+		return "", false
+	}
+	return next.String(), true
+}
+
+func redirectMethod(method string, status int) string {
+	switch {
+	case status == http.StatusSeeOther && method != "HEAD":
+		return "GET"
+	case (status == http.StatusMovedPermanently || status == http.StatusFound) && method == "POST":
+		return "GET"
+	default:
+		return method
+	}
+}
+
+func taintsOrigin(origin, current, next string) bool {
+	if origin == "" || origin == "null" {
+		return false
+	}
+
+	cur, err := url.Parse(current)
+	if err != nil {
+		return false
+	}
+	loc, err := url.Parse(next)
+	if err != nil {
+		return false
+	}
+
+	curOrigin := cur.Scheme + "://" + cur.Host
+	return curOrigin != loc.Scheme+"://"+loc.Host && origin != curOrigin
+}
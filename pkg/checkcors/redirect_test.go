@@ -0,0 +1,67 @@
+package checkcors
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTaintsOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		current string
+		next    string
+		want    bool
+	}{
+		{"SameOrigin", "https://a.example", "https://api.example/x", "https://api.example/y", false},
+		{"CrossOrigin", "https://a.example", "https://api.example/x", "https://cdn.example/y", true},
+		{"FromRequestOrigin", "https://a.example", "https://a.example/x", "https://cdn.example/y", false},
+		{"SchemeChange", "https://a.example", "http://api.example/x", "https://api.example/x", true},
+		{"PortChange", "https://a.example", "https://api.example/x", "https://api.example:8443/x", true},
+		{"NoOrigin", "", "https://api.example/x", "https://cdn.example/y", false},
+		{"AlreadyNull", "null", "https://api.example/x", "https://cdn.example/y", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := taintsOrigin(test.origin, test.current, test.next)
+			if got != test.want {
+				t.Errorf("taintsOrigin(%q, %q, %q) = %v, want %v", test.origin, test.current, test.next, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedirectMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		status int
+		want   string
+	}{
+		{"GET", http.StatusMovedPermanently, "GET"},
+		{"POST", http.StatusMovedPermanently, "GET"},
+		{"POST", http.StatusFound, "GET"},
+		{"PUT", http.StatusFound, "PUT"},
+		{"POST", http.StatusSeeOther, "GET"},
+		{"DELETE", http.StatusSeeOther, "GET"},
+		{"HEAD", http.StatusSeeOther, "HEAD"},
+		{"POST", http.StatusTemporaryRedirect, "POST"},
+		{"POST", http.StatusPermanentRedirect, "POST"},
+	}
+
+	for _, test := range tests {
+		got := redirectMethod(test.method, test.status)
+		if got != test.want {
+			t.Errorf("redirectMethod(%q, %v) = %q, want %q", test.method, test.status, got, test.want)
+		}
+	}
+}
+
+func TestEvalStatusRedirect(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect} {
+		v := evalStatus(status)
+		if v.OK {
+			t.Errorf("status %v passed, but a redirected preflight must fail", status)
+		}
+	}
+}
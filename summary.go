@@ -0,0 +1,128 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	exitFailure    = 1
+	exitUsage      = 2
+	exitMismatch   = 3
+	exitTransport  = 4
+	exitIncomplete = 5
+)
+
+type exitError struct {
+	code int
+	err  error
+}
+
+func (err exitError) Error() string {
+	return err.err.Error()
+}
+
+func (err exitError) Unwrap() error {
+	return err.err
+}
+
+func incomplete(ctx context.Context) error {
+	return exitError{
+		code: exitIncomplete,
+		err:  fmt.Errorf("run incomplete: %w", ctx.Err()),
+	}
+}
+
+type Summary struct {
+	Slowest int
+
+	m       sync.Mutex
+	total   int
+	passed  int
+	failed  int
+	errored int
+	ignored int
+	skipped int
+	records []Record
+}
+
+func (s *Summary) Report(record Record) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if record.Status == StatusSkipped {
+		s.skipped++
+		return
+	}
+
+	s.total++
+	switch record.Status {
+	case StatusPass:
+		s.passed++
+	case StatusFail:
+		s.failed++
+	case StatusError:
+		s.errored++
+	case StatusIgnored:
+		s.ignored++
+	}
+
+	s.records = append(s.records, record)
+	slices.SortFunc(s.records, func(r1, r2 Record) int { return cmp.Compare(r2.DurationMS, r1.DurationMS) })
+	if len(s.records) > s.Slowest {
+		s.records = s.records[:s.Slowest]
+	}
+}
+
+func (s *Summary) Print(w io.Writer) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	fmt.Fprintf(w, "checked %v: %v passed, %v failed, %v errored, %v ignored", s.total, s.passed, s.failed, s.errored, s.ignored)
+	if s.skipped != 0 {
+		fmt.Fprintf(w, ", %v skipped", s.skipped)
+	}
+	fmt.Fprintln(w)
+	if len(s.records) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "slowest:")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, record := range s.records {
+		d := time.Duration(record.DurationMS * float64(time.Millisecond)).Round(time.Millisecond)
+		fmt.Fprintf(tw, "  %v\t%v\n", d, record.Label())
+	}
+	return tw.Flush()
+}
+
+func (s *Summary) Err(threshold float64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.total == 0 {
+		return nil
+	}
+
+	bad := s.failed + s.errored
+	if float64(bad)/float64(s.total)*100 <= threshold {
+		return nil
+	}
+
+	if s.failed != 0 {
+		return exitError{
+			code: exitMismatch,
+			err:  fmt.Errorf("%v of %v checks did not match policy", s.failed, s.total),
+		}
+	}
+	return exitError{
+		code: exitTransport,
+		err:  fmt.Errorf("%v of %v checks could not be performed", s.errored, s.total),
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSummaryErr(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []Status
+		threshold float64
+		code      int
+	}{
+		{"Empty", nil, 0, 0},
+		{"Passed", []Status{StatusPass, StatusPass}, 0, 0},
+		{"Ignored", []Status{StatusPass, StatusIgnored}, 0, 0},
+		{"Skipped", []Status{StatusPass, StatusSkipped, StatusSkipped}, 0, 0},
+		{"SkippedNotCounted", []Status{StatusPass, StatusPass, StatusPass, StatusError, StatusSkipped}, 25, 0},
+		{"Failed", []Status{StatusPass, StatusFail}, 0, exitMismatch},
+		{"Errored", []Status{StatusPass, StatusError}, 0, exitTransport},
+		{"FailedAndErrored", []Status{StatusFail, StatusError}, 0, exitMismatch},
+		{"UnderThreshold", []Status{StatusPass, StatusPass, StatusPass, StatusError}, 25, 0},
+		{"OverThreshold", []Status{StatusPass, StatusPass, StatusPass, StatusError}, 20, exitTransport},
+		{"ThresholdCountsBoth", []Status{StatusPass, StatusPass, StatusFail, StatusError}, 25, exitMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var s Summary
+			for _, status := range test.statuses {
+				s.Report(Record{URL: "https://api.example/", Status: status})
+			}
+
+			err := s.Err(test.threshold)
+			if test.code == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var exit exitError
+			if !errors.As(err, &exit) {
+				t.Fatalf("err = %v, want exit code %v", err, test.code)
+			}
+			if exit.code != test.code {
+				t.Errorf("exit code = %v, want %v", exit.code, test.code)
+			}
+		})
+	}
+}
+
+func TestSummaryPrint(t *testing.T) {
+	s := Summary{Slowest: 1}
+	s.Report(Record{URL: "https://api.example/fast", Status: StatusPass, DurationMS: 1})
+	s.Report(Record{URL: "https://api.example/slow", Status: StatusFail, DurationMS: 100})
+
+	var out strings.Builder
+	err := s.Print(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "checked 2: 1 passed, 1 failed, 0 errored") {
+		t.Errorf("unexpected summary line: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "/slow") || strings.Contains(out.String(), "/fast") {
+		t.Errorf("slowest list should only contain /slow: %q", out.String())
+	}
+}
+
+func TestSummaryPrintSkipped(t *testing.T) {
+	var s Summary
+	s.Report(Record{URL: "https://api.example/a", Status: StatusPass})
+	s.Report(Record{URL: "https://api.example/b", Status: StatusSkipped})
+
+	var out strings.Builder
+	err := s.Print(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "checked 1: 1 passed, 0 failed, 0 errored, 0 ignored, 1 skipped\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    map[string]string
+		err     bool
+	}{
+		{
+			name:    "IPv4",
+			entries: []string{"API.example:443:127.0.0.1"},
+			want:    map[string]string{"api.example:443": "127.0.0.1:443"},
+		},
+		{
+			name:    "IPv6",
+			entries: []string{"api.example:8443:[::1]"},
+			want:    map[string]string{"api.example:8443": "[::1]:8443"},
+		},
+		{
+			name:    "Several",
+			entries: []string{"a.example:80:10.0.0.1", "b.example:80:10.0.0.2"},
+			want:    map[string]string{"a.example:80": "10.0.0.1:80", "b.example:80": "10.0.0.2:80"},
+		},
+		{name: "None", want: map[string]string{}},
+		{name: "MissingAddr", entries: []string{"api.example:443"}, err: true},
+		{name: "EmptyPort", entries: []string{"api.example::127.0.0.1"}, err: true},
+		{name: "Hostname", entries: []string{"api.example:443:localhost"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseResolve(test.entries)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
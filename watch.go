@@ -0,0 +1,226 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var durationBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type watchKey struct {
+	url       string
+	origin    string
+	method    string
+	preflight bool
+}
+
+func (key watchKey) labels() string {
+	return fmt.Sprintf("url=%v,origin=%v,method=%v,preflight=%v", metricLabel(key.url), metricLabel(key.origin), metricLabel(key.method), metricLabel(strconv.FormatBool(key.preflight)))
+}
+
+type Watcher struct {
+	m      sync.Mutex
+	state  map[watchKey]Record
+	seen   map[watchKey]struct{}
+	runs   int
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func NewWatcher() *Watcher {
+	return &Watcher{
+		state:  make(map[watchKey]Record),
+		counts: make([]uint64, len(durationBuckets)),
+	}
+}
+
+func (w *Watcher) start() {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.seen = make(map[watchKey]struct{})
+}
+
+func (w *Watcher) Report(record Record) {
+	if record.Status == StatusSkipped {
+		return
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	key := watchKey{url: record.URL, origin: record.Origin, method: record.Method, preflight: record.Preflight}
+	w.seen[key] = struct{}{}
+
+	seconds := record.DurationMS / 1000
+	for i, le := range durationBuckets {
+		if seconds <= le {
+			w.counts[i]++
+		}
+	}
+	w.sum += seconds
+	w.count++
+
+	prev, ok := w.state[key]
+	w.state[key] = record
+	if !ok && record.Status == StatusPass {
+		return
+	}
+	if ok && prev.Status == record.Status {
+		return
+	}
+
+	slog := slog.With("url", record.URL)
+	if record.Preflight || record.Method != "GET" {
+		slog = slog.With("method", record.Method)
+	}
+	if record.Origin != "" {
+		slog = slog.With("origin", record.Origin)
+	}
+	from := Status("unknown")
+	if ok {
+		from = prev.Status
+	}
+
+	if record.Status == StatusPass {
+		slog.Info("state changed", "from", from, "to", record.Status)
+		return
+	}
+	slog.Warn("state changed", "from", from, "to", record.Status)
+	(&logReporter{}).Report(record)
+}
+
+func (w *Watcher) finish() {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	for key := range w.state {
+		if _, ok := w.seen[key]; !ok {
+			delete(w.state, key)
+		}
+	}
+	w.runs++
+}
+
+func (w *Watcher) WriteMetrics(out io.Writer) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	keys := make([]watchKey, 0, len(w.state))
+	for key := range w.state {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(k1, k2 watchKey) int {
+		return cmp.Or(
+			strings.Compare(k1.url, k2.url),
+			strings.Compare(k1.origin, k2.origin),
+			strings.Compare(k1.method, k2.method),
+			cmp.Compare(metricBool(k1.preflight), metricBool(k2.preflight)),
+		)
+	})
+
+	fmt.Fprintln(out, "# HELP checkcors_check_passed Whether the most recent check of a URL passed.")
+	fmt.Fprintln(out, "# TYPE checkcors_check_passed gauge")
+	for _, key := range keys {
+		fmt.Fprintf(out, "checkcors_check_passed{%v} %v\n", key.labels(), metricBool(w.state[key].Status == StatusPass))
+	}
+
+	fmt.Fprintln(out, "# HELP checkcors_check_error Whether the most recent check of a URL could not be performed.")
+	fmt.Fprintln(out, "# TYPE checkcors_check_error gauge")
+	for _, key := range keys {
+		fmt.Fprintf(out, "checkcors_check_error{%v} %v\n", key.labels(), metricBool(w.state[key].Status == StatusError))
+	}
+
+	fmt.Fprintln(out, "# HELP checkcors_check_duration_seconds Time taken to check a URL, including retries.")
+	fmt.Fprintln(out, "# TYPE checkcors_check_duration_seconds histogram")
+	for i, le := range durationBuckets {
+		fmt.Fprintf(out, "checkcors_check_duration_seconds_bucket{le=\"%v\"} %v\n", le, w.counts[i])
+	}
+	fmt.Fprintf(out, "checkcors_check_duration_seconds_bucket{le=\"+Inf\"} %v\n", w.count)
+	fmt.Fprintf(out, "checkcors_check_duration_seconds_sum %v\n", w.sum)
+	fmt.Fprintf(out, "checkcors_check_duration_seconds_count %v\n", w.count)
+
+	fmt.Fprintln(out, "# HELP checkcors_runs_total Number of completed check runs.")
+	fmt.Fprintln(out, "# TYPE checkcors_runs_total counter")
+	fmt.Fprintf(out, "checkcors_runs_total %v\n", w.runs)
+}
+
+func (w *Watcher) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteMetrics(rw)
+}
+
+func metricLabel(val string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(val) + `"`
+}
+
+func metricBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func runWatch(ctx context.Context, interval time.Duration, addr string, check func(context.Context, func(Record)) error) error {
+	watcher := NewWatcher()
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", watcher)
+	mux.HandleFunc("GET /healthz", func(rw http.ResponseWriter, req *http.Request) {
+		io.WriteString(rw, "ok\n")
+	})
+
+	server := http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	slog.Info("watching", "interval", interval, "addr", addr)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		watcher.start()
+		err := check(ctx, watcher.Report)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			slog.Error("load URLs", "err", err)
+		} else {
+			watcher.finish()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-serverErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return fmt.Errorf("serve metrics: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWatcherMethods(t *testing.T) {
+	w := NewWatcher()
+	for range 2 {
+		w.start()
+		w.Report(Record{URL: "https://api.example/", Origin: "https://a.example", Method: "GET", Status: StatusPass})
+		w.Report(Record{URL: "https://api.example/", Origin: "https://a.example", Method: "DELETE", Preflight: true, Status: StatusFail})
+		w.finish()
+	}
+
+	if len(w.state) != 2 {
+		t.Fatalf("got %v watch states, want 2: %v", len(w.state), w.state)
+	}
+
+	var out strings.Builder
+	w.WriteMetrics(&out)
+	for _, line := range []string{
+		`checkcors_check_passed{url="https://api.example/",origin="https://a.example",method="DELETE",preflight="true"} 0`,
+		`checkcors_check_passed{url="https://api.example/",origin="https://a.example",method="GET",preflight="false"} 1`,
+		`checkcors_runs_total 2`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("metrics missing %q:\n%v", line, out.String())
+		}
+	}
+}
+
+func TestMetricLabel(t *testing.T) {
+	got := metricLabel("a\"b\\c\nd")
+	if want := `"a\"b\\c\nd"`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}